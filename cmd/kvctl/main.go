@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/khighness/highness-paxos-kv/core"
+	"github.com/khighness/highness-paxos-kv/pkg/logging"
+	"github.com/khighness/highness-paxos-kv/pkg/shard"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+const usage = `Usage: kvctl [flags] <command> [args]
+
+Commands:
+  shard show                      print the latest shard map
+  shard init <mode> <groups> [splits]
+                                  publish the initial shard map, mode is range or hash,
+                                  groups are separated by '/', e.g. 3,4,5/6,7,8,
+                                  splits are the split keys of a range map, e.g. m
+  shard split <key>               split the range containing the routing key at it
+  shard merge <start>             merge the range starting at the key into the previous one
+  shard move <start> <group>      move the range starting at the key to the group
+  shard namespace <namespace>     move the keys of the namespace with the ranges
+  log level <acceptor> [<subsystem> <level>]
+                                  print the log levels of the process of the acceptor,
+                                  or change the level of a subsystem, e.g. acceptor debug
+  status <acceptor>                print the status of the acceptor
+  audit <acceptor> <key> [namespace]
+                                  print the changes of the votes of the acceptor on the key
+
+Flags:
+`
+
+func main() {
+	config := flag.String("config", "0,1,2", "acceptor ids of the config group")
+	proposerId := flag.Int64("proposer", 1, "proposer id to run paxos with")
+	tlsCert := flag.String("tls-cert", "", "client certificate file for mutual TLS")
+	tlsKey := flag.String("tls-key", "", "client key file for mutual TLS")
+	tlsCA := flag.String("tls-ca", "", "CA file verifying acceptors, enables TLS")
+	token := flag.String("token", "", "bearer token to present to acceptors")
+	logLevel := flag.String("log-level", "", "level of logs written to stderr, empty for no logs")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *logLevel != "" {
+		closeLog, err := logging.Setup(logging.Config{Level: *logLevel, OutputPaths: []string{"stderr"}})
+		if err != nil {
+			fail(err)
+		}
+		defer closeLog()
+	}
+
+	configGroup, err := parseGroup(*config)
+	if err != nil {
+		fail(err)
+	}
+	if *tlsCA != "" || *tlsCert != "" {
+		core.ClientTLS = &core.TLSConfig{CertFile: *tlsCert, KeyFile: *tlsKey, CAFile: *tlsCA}
+	}
+	core.ClientToken = *token
+
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "shard":
+		err = runShard(shard.NewAdmin(configGroup, *proposerId), args[1], args[2:])
+	case "log":
+		err = runLog(args[1], args[2:])
+	case "status":
+		err = runStatus(args[1:])
+	case "audit":
+		err = runAudit(args[1:])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func runShard(admin *shard.Admin, cmd string, args []string) error {
+	switch {
+	case cmd == "show" && len(args) == 0:
+		m, err := admin.Map()
+		if err != nil {
+			return err
+		}
+		b, _ := json.MarshalIndent(m, "", "  ")
+		fmt.Printf("version: %d\n%s\n", m.Version, b)
+		return nil
+	case cmd == "init" && (len(args) == 2 || len(args) == 3):
+		var groups [][]int64
+		for _, s := range strings.Split(args[1], "/") {
+			group, err := parseGroup(s)
+			if err != nil {
+				return err
+			}
+			groups = append(groups, group)
+		}
+
+		var m *shard.Map
+		var err error
+		switch args[0] {
+		case shard.ModeRange:
+			var splits []string
+			if len(args) == 3 {
+				splits = strings.Split(args[2], ",")
+			}
+			m, err = shard.NewRangeMap(splits, groups)
+		case shard.ModeHash:
+			m, err = shard.NewHashMap(groups)
+		default:
+			err = fmt.Errorf("unknown mode: %s", args[0])
+		}
+		if err != nil {
+			return err
+		}
+		return admin.Init(m)
+	case cmd == "split" && len(args) == 1:
+		return admin.Split(args[0])
+	case cmd == "merge" && len(args) == 1:
+		return admin.Merge(args[0])
+	case cmd == "move" && len(args) == 2:
+		group, err := parseGroup(args[1])
+		if err != nil {
+			return err
+		}
+		return admin.Move(args[0], group)
+	case cmd == "namespace" && len(args) == 1:
+		return admin.AddNamespace(args[0])
+	}
+
+	flag.Usage()
+	os.Exit(2)
+	return nil
+}
+
+func runLog(cmd string, args []string) error {
+	if cmd != "level" || len(args) != 1 && len(args) != 3 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	aid, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid acceptor id: %q", args[0])
+	}
+	var subsystem, level string
+	if len(args) == 3 {
+		subsystem, level = args[1], args[2]
+	}
+
+	levels, err := core.SetLogLevel(aid, subsystem, level)
+	if err != nil {
+		return err
+	}
+	for _, subsystem := range logging.Subsystems() {
+		fmt.Printf("%-10s %s\n", subsystem, levels[subsystem])
+	}
+	return nil
+}
+
+func runStatus(args []string) error {
+	if len(args) != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	aid, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid acceptor id: %q", args[0])
+	}
+
+	status, err := core.StatusOf(aid)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("acceptor:   %d\n", status.AcceptorId)
+	fmt.Printf("uptime:     %s\n", time.Duration(status.Uptime).Round(time.Second))
+	fmt.Printf("ready:      %t\n", status.Ready)
+	fmt.Printf("keys:       %d\n", status.Keys)
+	fmt.Printf("versions:   %d\n", status.Versions)
+	fmt.Printf("bytes:      %d\n", status.Bytes)
+	fmt.Printf("last index: %d\n", status.LastIndex)
+	return nil
+}
+
+func runAudit(args []string) error {
+	if len(args) != 2 && len(args) != 3 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	aid, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid acceptor id: %q", args[0])
+	}
+	var namespace string
+	if len(args) == 3 {
+		namespace = args[2]
+	}
+
+	records, err := core.AuditOf(aid, namespace, args[1], 0)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		b, err := protojson.Marshal(record)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	}
+	return nil
+}
+
+func parseGroup(s string) ([]int64, error) {
+	var group []int64
+	for _, id := range strings.Split(s, ",") {
+		aid, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid acceptor id: %q", id)
+		}
+		group = append(group, aid)
+	}
+	return group, nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "kvctl:", err)
+	os.Exit(1)
+}
@@ -2,15 +2,18 @@ package core
 
 import (
 	"context"
-	"fmt"
-	"net"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
-	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
-
-	_ "github.com/khighness/highness-paxos-kv/pkg/logging"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // @Author KHighness
@@ -27,70 +30,421 @@ func (a *BallotNum) GE(b *BallotNum) bool {
 	return a.ProposerId >= b.ProposerId
 }
 
+var (
+	ErrCompacted = status.Error(codes.OutOfRange, "version has been reclaimed")
+	// ErrLogCompacted rejects a subscriber resuming from a position of the
+	// commit log whose instances have been reclaimed, see CollectGarbage.
+	ErrLogCompacted = status.Error(codes.OutOfRange, "commit log position has been compacted")
+	// ErrNotVoted rejects a Commit of a value the Acceptor has not voted at the ballot.
+	ErrNotVoted = status.Error(codes.FailedPrecondition, "value has not been voted at the ballot")
+)
+
+// SubscribeHeartbeat is the interval an Acceptor sends heartbeats to an idle subscriber.
+var SubscribeHeartbeat = 100 * time.Millisecond
+
 // Version defines one modification of a key-value record.
 type Version struct {
 	mu       sync.Mutex
 	acceptor Acceptor
+	// chosen is the committed value, nil if the Acceptor has not learned it.
+	chosen *Value
+	// index is the position of this version in the commit log.
+	index int64
+	// preparer is the principal of the caller who has prepared LastBal, with
+	// access control.
+	preparer string
 }
 
 // Versions stores all version of a record.
 type Versions map[int64]*Version
 
 // KVServer implements the paxos Acceptor API, handling Prepare and Accept request.
+//
+// Records of a namespace other than the default one are stored in Storage with
+// the namespace prefixed to the key, see storageKey.
 type KVServer struct {
+	id int64
+	// created is the time the Acceptor is created at.
+	created time.Time
+	// ready is 1 if the Acceptor is ready to serve paxos requests, accessed atomically.
+	ready  int32
+	health *health.Server
+	// draining is closed when the Acceptor starts to shut down.
+	drainMu  sync.Mutex
+	draining chan struct{}
+
 	mu      sync.Mutex
 	Storage map[string]Versions
+	// keys is the ordered index of the keys in Storage.
+	keys []string
+	// floors stores the lowest version not reclaimed of every key.
+	floors map[string]int64
+	// reclaimedAt stores the time every key is reclaimed entirely at, its
+	// floor is dropped FloorRetention after.
+	reclaimedAt map[string]time.Time
+	// gcInterval is the interval to collect garbage at, 0 for never.
+	gcInterval time.Duration
+
+	// fenceMu protects the fence, Prepare and Accept hold it for reading, it
+	// is always acquired before Version.mu.
+	fenceMu sync.RWMutex
+	// fence is the lowest epoch of Proposers to take Prepare and Accept of.
+	fence int64
+
+	// usageMu protects the quotas and usages of namespaces, it is always
+	// acquired after Version.mu.
+	usageMu sync.Mutex
+	quotas  map[string]Quota
+	usages  map[string]*usage
+
+	// tls is the TLS configuration to serve with, nil for plaintext.
+	tls *TLSConfig
+	// access authorizes the requests, nil for no access control.
+	access *AccessControl
+
+	metrics         *acceptorMetrics
+	metricsBasePort int
+	healthBasePort  int
+
+	// audit writes the changes of votes, nil for no auditing.
+	audit *auditWriter
+	// auditMu protects the audit records, it is always acquired after Version.mu.
+	auditMu      sync.Mutex
+	auditRecords map[string][]*AuditRecord
+
+	// logMu protects the commit log, it is always acquired after Version.mu.
+	logMu     sync.Mutex
+	commitLog []*Instance
+	appended  chan struct{}
+	// lastIndex is the position of the last instance appended to the commit
+	// log, logCompacted the highest position of the instances dropped from it.
+	lastIndex    int64
+	logCompacted int64
 }
 
 // Prepare handles Prepare request.
-func (s *KVServer) Prepare(c context.Context, r *Proposer) (*Acceptor, error) {
-	zap.S().Infof("Acceptor: receive Prepare request: %v", r)
+func (s *KVServer) Prepare(c context.Context, r *Proposer) (_ *Acceptor, err error) {
+	start, outcome := time.Now(), outcomePromised
+	defer func() { s.logRequest("Prepare", r, outcome, start, err) }()
 
-	v := s.getVersionLocked(r.Id)
+	release, err := s.admit(r.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	v, err := s.getVersionLocked(r.Id, true)
+	if err != nil {
+		return nil, err
+	}
 	defer v.mu.Unlock()
 
-	reply := v.acceptor
+	reply := proto.Clone(&v.acceptor).(*Acceptor)
+
+	// The ballot prepared by a caller is never taken over by another one.
+	principal, privileged := s.caller(c)
+	if !privileged && proto.Equal(r.Bal, v.acceptor.LastBal) && principal != v.preparer {
+		return nil, ErrPermissionDenied
+	}
 
 	if r.Bal.GE(v.acceptor.LastBal) {
 		v.acceptor.LastBal = r.Bal
+		v.preparer = principal
+		s.recordChange(c, "Prepare", r.Id, reply, v)
+	} else {
+		outcome = outcomeLowerBallot
+		s.metrics.reject("Prepare", outcomeLowerBallot)
 	}
 
-	return &reply, nil
+	return reply, nil
 }
 
 // Accept handles Accept request.
-func (s *KVServer) Accept(c context.Context, r *Proposer) (*Acceptor, error) {
-	zap.S().Infof("Acceptor: receive Accept request: %v", r)
+//
+// With access control, an Accept of a caller not privileged is taken only at
+// the ballot it has prepared itself, see AccessControl.
+func (s *KVServer) Accept(c context.Context, r *Proposer) (_ *Acceptor, err error) {
+	start, outcome := time.Now(), outcomeAccepted
+	defer func() { s.logRequest("Accept", r, outcome, start, err) }()
 
-	v := s.getVersionLocked(r.Id)
+	release, err := s.admit(r.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	v, err := s.getVersionLocked(r.Id, true)
+	if err != nil {
+		return nil, err
+	}
 	defer v.mu.Unlock()
 
-	d := *v.acceptor.LastBal
-	reply := Acceptor{LastBal: &d}
+	reply := Acceptor{LastBal: proto.Clone(v.acceptor.LastBal).(*BallotNum)}
+	old := s.auditSnapshot(v)
+
+	if principal, privileged := s.caller(c); !privileged && r.Bal.GE(v.acceptor.LastBal) &&
+		(!proto.Equal(r.Bal, v.acceptor.LastBal) || principal != v.preparer) {
+		return nil, ErrPermissionDenied
+	}
 
 	if r.Bal.GE(v.acceptor.LastBal) {
+		// Tombstones are always accepted, so that a full namespace can be freed.
+		delta := int64(proto.Size(r.Val) - proto.Size(v.acceptor.Val))
+		if err = s.useVote(r.Id.Namespace, r.Id.Key, delta, !r.Val.GetTombstone()); err != nil {
+			return nil, err
+		}
+
 		v.acceptor.LastBal = r.Bal
 		v.acceptor.Val = r.Val
 		v.acceptor.VBal = r.Bal
+		s.recordChange(c, "Accept", r.Id, old, v)
+	} else {
+		outcome = outcomeLowerBallot
+		s.metrics.reject("Accept", outcomeLowerBallot)
 	}
 
 	return &reply, nil
 }
 
-func (s *KVServer) getVersionLocked(id *PaxosInstanceId) *Version {
+// Commit handles Commit request, the value in request has been chosen by a quorum.
+//
+// A Commit is taken only if the Acceptor has voted the same value at the same
+// ballot, as every Acceptor of the quorum choosing it has, so that a value
+// never voted cannot be put into the commit log. An Acceptor which has not
+// voted it learns it when paxos runs on the instance again.
+func (s *KVServer) Commit(c context.Context, r *Proposer) (_ *Acceptor, err error) {
+	start, outcome := time.Now(), outcomeKnown
+	defer func() { s.logRequest("Commit", r, outcome, start, err) }()
+
+	v, err := s.getVersionLocked(r.Id, false)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrNotVoted
+	}
+	defer v.mu.Unlock()
+
+	reply := Acceptor{LastBal: proto.Clone(v.acceptor.LastBal).(*BallotNum)}
+
+	switch {
+	case v.chosen != nil && proto.Equal(v.chosen, r.Val):
+	case v.chosen == nil && proto.Equal(r.Bal, v.acceptor.VBal) && proto.Equal(r.Val, v.acceptor.Val):
+		v.chosen = r.Val
+		v.index = s.appendCommitLog(r.Id, r.Val)
+		outcome = outcomeCommitted
+	default:
+		return nil, ErrNotVoted
+	}
+
+	return &reply, nil
+}
+
+// Subscribe streams the commit log from the requested position, then keeps
+// streaming newly committed instances until the subscriber goes away.
+func (s *KVServer) Subscribe(r *SubscribeRequest, stream PaxosKV_SubscribeServer) error {
+	acceptorLog.Infof("Acceptor: receive Subscribe request: %v", r)
+
+	return s.tailCommitLog(stream.Context(), r.FromIndex, SubscribeHeartbeat, stream.Send)
+}
+
+// Watch streams the committed versions of a key, or of the keys with a prefix,
+// from the requested version, then keeps streaming newly committed versions.
+// Transaction records are left out unless the key is in TxnRecordPrefix.
+func (s *KVServer) Watch(r *WatchRequest, stream PaxosKV_WatchServer) error {
+	acceptorLog.Infof("Acceptor: receive Watch request: %v", r)
+
+	if err := validateKey(r.Namespace, r.Key); err != nil {
+		return err
+	}
+
+	return s.tailCommitLog(stream.Context(), 1, 0, func(inst *Instance) error {
+		if inst.Id == nil || inst.Id.Namespace != r.Namespace || inst.Id.Ver < r.FromVer {
+			return nil
+		}
+		if inst.Id.Key != r.Key && !(r.Prefix && strings.HasPrefix(inst.Id.Key, r.Key)) {
+			return nil
+		}
+		if hiddenTxnRecord(inst.Id.Key, r.Key) {
+			return nil
+		}
+		return stream.Send(inst)
+	})
+}
+
+// LatestVersion handles LatestVersion request.
+func (s *KVServer) LatestVersion(c context.Context, r *LatestVersionRequest) (_ *LatestVersionReply, err error) {
+	start := time.Now()
+	defer func() { s.logKeyRequest("LatestVersion", r.Namespace, r.Key, start, err) }()
+
+	if err = validateKey(r.Namespace, r.Key); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := storageKey(r.Namespace, r.Key)
+	reply := &LatestVersionReply{VotedVer: -1, CommittedVer: -1, Floor: s.floors[key]}
+	for ver, v := range s.Storage[key] {
+		v.mu.Lock()
+		if v.acceptor.Val != nil && ver > reply.VotedVer {
+			reply.VotedVer = ver
+		}
+		if v.chosen != nil && ver > reply.CommittedVer {
+			reply.CommittedVer = ver
+		}
+		v.mu.Unlock()
+	}
+
+	return reply, nil
+}
+
+// tailCommitLog sends the commit log from position `from`, then keeps sending
+// newly committed instances until ctx is done or the Acceptor shuts down.
+// If heartbeat is positive, an Instance without Id is sent periodically while
+// there is nothing to send.
+//
+// Reading from position 1 gets the instances not reclaimed. It returns
+// ErrLogCompacted if it resumes from, or falls behind to, a compacted position,
+// since the instances missed may have deleted keys.
+func (s *KVServer) tailCommitLog(ctx context.Context, from int64, heartbeat time.Duration, send func(*Instance) error) error {
+	if from < 1 {
+		from = 1
+	}
+	resumed := from > 1
+
+	draining := s.drained()
+
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		instances, appended, err := s.readCommitLog(from, resumed)
+		if err != nil {
+			return err
+		}
+		resumed = true
+		for _, inst := range instances {
+			if err := send(inst); err != nil {
+				return err
+			}
+			from = inst.Index + 1
+		}
+		if len(instances) > 0 {
+			continue
+		}
+
+		if heartbeat > 0 {
+			if err := send(&Instance{Index: from - 1}); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-draining:
+			return ErrShuttingDown
+		case <-appended:
+		case <-tick:
+		}
+	}
+}
+
+// appendCommitLog appends a chosen instance to the commit log and returns its position.
+func (s *KVServer) appendCommitLog(id *PaxosInstanceId, val *Value) int64 {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+
+	s.lastIndex += 1
+	inst := &Instance{Id: id, Val: val, Index: s.lastIndex}
+	s.commitLog = append(s.commitLog, inst)
+
+	if s.appended != nil {
+		close(s.appended)
+		s.appended = nil
+	}
+	return inst.Index
+}
+
+// readCommitLog returns the instances from position `from` to the end of the
+// commit log, and a channel which is closed when the next instance is appended.
+// If resumed, it returns ErrLogCompacted if an instance from the position has
+// been dropped.
+func (s *KVServer) readCommitLog(from int64, resumed bool) ([]*Instance, <-chan struct{}, error) {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+
+	if resumed && from <= s.logCompacted {
+		return nil, nil, ErrLogCompacted
+	}
+	if s.appended == nil {
+		s.appended = make(chan struct{})
+	}
+
+	i := sort.Search(len(s.commitLog), func(i int) bool { return s.commitLog[i].Index >= from })
+	instances := append([]*Instance(nil), s.commitLog[i:]...)
+	return instances, s.appended, nil
+}
+
+// compactCommitLog drops the instances of the versions reclaimed from the
+// commit log, the positions of the others are kept. s.mu must be held.
+func (s *KVServer) compactCommitLog() int {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+
+	kept := make([]*Instance, 0, len(s.commitLog))
+	for _, inst := range s.commitLog {
+		if inst.Id.Ver < s.floors[storageKey(inst.Id.Namespace, inst.Id.Key)] {
+			s.logCompacted = inst.Index
+			continue
+		}
+		kept = append(kept, inst)
+	}
+	dropped := len(s.commitLog) - len(kept)
+	s.commitLog = kept
+	return dropped
+}
+
+// getVersionLocked returns the locked version of the instance. If create is
+// set, the version is created if not exists, otherwise nil is returned if not
+// exists. A new key is accounted against the quota on its first vote.
+func (s *KVServer) getVersionLocked(id *PaxosInstanceId, create bool) (*Version, error) {
+	defer s.metrics.observeStorage(time.Now())
+
+	if err := validateKey(id.Namespace, id.Key); err != nil {
+		return nil, err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	key := id.Key
+	key := storageKey(id.Namespace, id.Key)
 	ver := id.Ver
+	if ver < s.floors[key] {
+		return nil, ErrCompacted
+	}
 	versions, ok := s.Storage[key]
+	if !ok && !create {
+		return nil, nil
+	}
 	if !ok {
 		versions = Versions{}
 		s.Storage[key] = versions
+		s.indexKey(key)
 	}
 
 	v, ok := versions[ver]
+	if !ok && !create {
+		return nil, nil
+	}
 	if !ok {
+		s.useVersions(id.Namespace, 1)
 		versions[ver] = &Version{
 			acceptor: Acceptor{
 				LastBal: &BallotNum{},
@@ -101,28 +455,72 @@ func (s *KVServer) getVersionLocked(id *PaxosInstanceId) *Version {
 	}
 
 	v.mu.Lock()
-	return v
+	return v, nil
 }
 
-// ServeAcceptors starts a gRPC server for every acceptor.
-func ServeAcceptors(acceptorIds []int64) []*grpc.Server {
-	var servers []*grpc.Server
+// ServerOption configures the acceptors started by ServeAcceptors.
+type ServerOption func(*KVServer)
+
+// WithQuota limits the usage of a namespace on every acceptor.
+func WithQuota(namespace string, q Quota) ServerOption {
+	return func(s *KVServer) {
+		s.SetQuota(namespace, q)
+	}
+}
+
+// NewKVServer creates an Acceptor with empty storage.
+func NewKVServer(opts ...ServerOption) *KVServer {
+	s := &KVServer{Storage: map[string]Versions{}, metrics: newAcceptorMetrics(), created: time.Now(),
+		draining: make(chan struct{})}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ServeAcceptors starts a gRPC server for every acceptor, which also serves
+// the standard gRPC health service. The acceptors are stopped with the
+// returned handle.
+func ServeAcceptors(acceptorIds []int64, opts ...ServerOption) *Acceptors {
+	acceptors := &Acceptors{}
 
 	for _, aid := range acceptorIds {
-		addr := fmt.Sprintf(":%d", AcceptorBasePort+int(aid))
+		s := NewKVServer(opts...)
+		s.id = aid
 
-		listener, err := net.Listen("tcp", addr)
-		if err != nil {
-			zap.S().Fatalf("listen: %s %v", addr, err)
+		as := &AcceptorServer{Id: aid, kv: s}
+		if err := as.start(); err != nil {
+			acceptorLog.Fatalf("Acceptor-%d: failed to serve: %v", aid, err)
 		}
+		acceptors.servers = append(acceptors.servers, as)
+	}
+
+	return acceptors
+}
 
-		server := grpc.NewServer()
-		RegisterPaxosKVServer(server, &KVServer{Storage: map[string]Versions{}})
-		reflection.Register(server)
-		zap.S().Infof("Acceptor-%d is serving on %s", aid, addr)
-		servers = append(servers, server)
-		go server.Serve(listener)
+// newGRPCServer creates the gRPC server of the Acceptor.
+func (s *KVServer) newGRPCServer() (*grpc.Server, error) {
+	var serverOpts []grpc.ServerOption
+	if s.tls != nil {
+		creds, err := s.tls.ServerCredentials()
+		if err != nil {
+			return nil, err
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	}
+	unary := []grpc.UnaryServerInterceptor{traceUnaryInterceptor, s.metrics.UnaryInterceptor}
+	stream := []grpc.StreamServerInterceptor{s.metrics.StreamInterceptor}
+	if s.access != nil {
+		unary = append(unary, s.access.UnaryInterceptor)
+		stream = append(stream, s.access.StreamInterceptor)
 	}
+	serverOpts = append(serverOpts,
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...))
 
-	return servers
+	server := grpc.NewServer(serverOpts...)
+	RegisterPaxosKVServer(server, s)
+	grpc_health_v1.RegisterHealthServer(server, s.newHealthServer())
+	reflection.Register(server)
+	return server, nil
 }
@@ -0,0 +1,346 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+var (
+	// AuditQueueSize is the number of audit records queued for the output. If
+	// the output falls behind by more, records are dropped and a line with
+	// the number of them, e.g. {"Dropped":3,"Time":...}, is written in their
+	// place.
+	AuditQueueSize = 4096
+	// MaxAuditRecords is the number of the latest audit records of a key kept
+	// in memory, the older ones are dropped.
+	MaxAuditRecords = 256
+)
+
+// auditWriter appends audit records to an output shared by the acceptors.
+//
+// Records are queued and written in order in the background while any of the
+// acceptors serves, so that voting never waits for the output. Records queued
+// while none serves are written once one does, or by flush.
+type auditWriter struct {
+	// dropped is the number of records dropped since the last gap marker
+	// queued, accessed atomically.
+	dropped int64
+	out     io.Writer
+	queue   chan auditItem
+
+	mu sync.Mutex
+	// stop stops the writing goroutine, nil if it is not running.
+	stop chan struct{}
+	done chan struct{}
+	// flushing tracks the flushes waiting for the writing goroutine, which
+	// is stopped after them.
+	flushing *sync.WaitGroup
+	// refs is the number of serving acceptors.
+	refs int
+}
+
+// auditItem is a record to write, a gap marker if dropped is positive, or a
+// request to sync the output if synced is not nil.
+type auditItem struct {
+	record  *AuditRecord
+	dropped int64
+	synced  chan error
+}
+
+// WithAudit records every change of the votes of the acceptors, i.e. of
+// `LastBal`, `VBal` or `Val` of an instance, with the caller making it.
+//
+// The latest MaxAuditRecords records of every key are kept in memory to be
+// queried with Audit until their versions are reclaimed, and appended to out
+// as JSON lines if out is not nil. The output is shared by the acceptors
+// started with the option.
+func WithAudit(out io.Writer) ServerOption {
+	w := &auditWriter{out: out}
+	if out != nil {
+		w.queue = make(chan auditItem, AuditQueueSize)
+	}
+	return func(s *KVServer) {
+		s.audit = w
+	}
+}
+
+// startAudit starts writing the audit records of the Acceptor in the
+// background, and returns a function stopping it once the queued records
+// are written, which may be called more than once.
+func (s *KVServer) startAudit() func() {
+	if s.audit == nil || s.audit.out == nil {
+		return func() {}
+	}
+
+	w := s.audit
+	w.mu.Lock()
+	w.refs += 1
+	if w.refs == 1 {
+		w.stop, w.done, w.flushing = make(chan struct{}), make(chan struct{}), &sync.WaitGroup{}
+		go w.run(w.stop, w.done)
+	}
+	w.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			w.refs -= 1
+			if w.refs > 0 {
+				return
+			}
+			w.flushing.Wait()
+			close(w.stop)
+			<-w.done
+			w.stop, w.done, w.flushing = nil, nil, nil
+		})
+	}
+}
+
+// run writes the queued records until stopped, and then the records left.
+func (w *auditWriter) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	for {
+		select {
+		case item := <-w.queue:
+			w.handle(item)
+		case <-stop:
+			w.drain()
+			return
+		}
+	}
+}
+
+// drain writes the queued records, including a gap marker for the records
+// dropped after them.
+func (w *auditWriter) drain() {
+	for {
+		select {
+		case item := <-w.queue:
+			w.handle(item)
+		default:
+			if n := atomic.SwapInt64(&w.dropped, 0); n > 0 {
+				w.writeGap(n)
+			}
+			return
+		}
+	}
+}
+
+// handle writes a queued item or syncs the output.
+func (w *auditWriter) handle(item auditItem) {
+	switch {
+	case item.synced != nil:
+		item.synced <- w.sync()
+	case item.dropped > 0:
+		w.writeGap(item.dropped)
+	default:
+		w.write(item.record)
+	}
+}
+
+// enqueue queues a record to append to the output, or drops it if the queue
+// is full. It never blocks, as it is called with the lock of a version held.
+func (w *auditWriter) enqueue(record *AuditRecord) {
+	if w.out == nil {
+		return
+	}
+
+	// The records dropped are marked before the records queued after them.
+	if n := atomic.SwapInt64(&w.dropped, 0); n > 0 {
+		select {
+		case w.queue <- auditItem{dropped: n}:
+		default:
+			atomic.AddInt64(&w.dropped, n)
+			w.drop()
+			return
+		}
+	}
+	select {
+	case w.queue <- auditItem{record: record}:
+	default:
+		w.drop()
+	}
+}
+
+// drop counts a record dropped.
+func (w *auditWriter) drop() {
+	auditRecordsDropped.Inc()
+	if atomic.AddInt64(&w.dropped, 1) == 1 {
+		acceptorLog.Errorf("Acceptor: audit output falls behind, dropping records")
+	}
+}
+
+// flush waits for the queued records to be written and syncs the output if
+// it is a file.
+func (w *auditWriter) flush() error {
+	w.mu.Lock()
+	if w.stop == nil {
+		// Nothing else writes to the output while w.mu is held.
+		defer w.mu.Unlock()
+		w.drain()
+		return w.sync()
+	}
+	flushing := w.flushing
+	flushing.Add(1)
+	w.mu.Unlock()
+	defer flushing.Done()
+
+	if n := atomic.SwapInt64(&w.dropped, 0); n > 0 {
+		w.queue <- auditItem{dropped: n}
+	}
+	synced := make(chan error, 1)
+	w.queue <- auditItem{synced: synced}
+	return <-synced
+}
+
+// write appends a record to the output, one JSON object per line.
+func (w *auditWriter) write(record *AuditRecord) {
+	b, err := protojson.Marshal(record)
+	if err != nil {
+		acceptorLog.Errorf("Acceptor: failed to encode audit record: %v", err)
+		return
+	}
+
+	if _, err = w.out.Write(append(b, '\n')); err != nil {
+		acceptorLog.Errorf("Acceptor: failed to write audit record: %v", err)
+	}
+}
+
+// writeGap appends a gap marker for n records dropped to the output.
+func (w *auditWriter) writeGap(n int64) {
+	line := fmt.Sprintf("{\"Dropped\":%d,\"Time\":%d}\n", n, time.Now().UnixNano())
+	if _, err := io.WriteString(w.out, line); err != nil {
+		acceptorLog.Errorf("Acceptor: failed to write audit record: %v", err)
+	}
+}
+
+// sync syncs the output if it is a file.
+func (w *auditWriter) sync() error {
+	if f, ok := w.out.(interface{ Sync() error }); ok {
+		return f.Sync()
+	}
+	return nil
+}
+
+// auditSnapshot returns a copy of the state of a version to audit a change
+// against, nil if auditing is off. v.mu must be held.
+func (s *KVServer) auditSnapshot(v *Version) *Acceptor {
+	if s.audit == nil {
+		return nil
+	}
+	return proto.Clone(&v.acceptor).(*Acceptor)
+}
+
+// recordChange records the change of the state of a version from old made by
+// the request, if any. v.mu must be held, so that the records of a version are
+// in the order of the changes.
+func (s *KVServer) recordChange(ctx context.Context, method string, id *PaxosInstanceId, old *Acceptor, v *Version) {
+	if s.audit == nil || proto.Equal(old, &v.acceptor) {
+		return
+	}
+
+	record := &AuditRecord{
+		Id:         id,
+		Method:     method,
+		Old:        old,
+		New:        proto.Clone(&v.acceptor).(*Acceptor),
+		Time:       time.Now().UnixNano(),
+		AcceptorId: s.id,
+	}
+	if s.access != nil {
+		record.Caller, _ = s.access.authenticate(ctx)
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		record.Peer = p.Addr.String()
+	}
+
+	s.auditMu.Lock()
+	if s.auditRecords == nil {
+		s.auditRecords = map[string][]*AuditRecord{}
+	}
+	key := storageKey(id.Namespace, id.Key)
+	records := append(s.auditRecords[key], record)
+	if len(records) > MaxAuditRecords {
+		records = records[len(records)-MaxAuditRecords:]
+	}
+	s.auditRecords[key] = records
+	s.auditMu.Unlock()
+
+	s.audit.enqueue(record)
+}
+
+// pruneAudit drops the records of the versions of a key below floor.
+func (s *KVServer) pruneAudit(key string, floor int64) {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	var kept []*AuditRecord
+	for _, record := range s.auditRecords[key] {
+		if record.Id.Ver >= floor {
+			kept = append(kept, record)
+		}
+	}
+	if len(kept) == 0 {
+		delete(s.auditRecords, key)
+	} else {
+		s.auditRecords[key] = kept
+	}
+}
+
+// Audit handles Audit request, returning the audit records of the versions of
+// the key from FromVer, ordered by version and then by time.
+func (s *KVServer) Audit(c context.Context, r *AuditRequest) (_ *AuditReply, err error) {
+	start := time.Now()
+	defer func() { s.logKeyRequest("Audit", r.Namespace, r.Key, start, err) }()
+
+	if err = validateKey(r.Namespace, r.Key); err != nil {
+		return nil, err
+	}
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	reply := &AuditReply{}
+	for _, record := range s.auditRecords[storageKey(r.Namespace, r.Key)] {
+		if record.Id.Ver >= r.FromVer {
+			reply.Records = append(reply.Records, record)
+		}
+	}
+	sort.SliceStable(reply.Records, func(i, j int) bool {
+		return reply.Records[i].Id.Ver < reply.Records[j].Id.Ver
+	})
+	return reply, nil
+}
+
+// AuditOf returns the audit records of the versions of a key from fromVer on
+// an Acceptor.
+func AuditOf(aid int64, namespace, key string, fromVer int64) ([]*AuditRecord, error) {
+	conn, err := dialAcceptor(aid)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	reply, err := NewPaxosKVClient(conn).Audit(ctx, &AuditRequest{Namespace: namespace, Key: key, FromVer: fromVer})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Records, nil
+}
@@ -0,0 +1,195 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+// syncBuffer is a bytes.Buffer safe to write and read concurrently.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAudit(t *testing.T) {
+	r := require.New(t)
+
+	out := &syncBuffer{}
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds, WithAudit(out), withTestTLS(t, r), WithAccessControl(&AccessControl{
+		Tokens: map[string]string{"t-alice": "alice", "t-bob": "bob"},
+		Rules: []Rule{
+			{Principal: "alice", Prefix: "alice/", Permission: PermAdmin},
+			{Principal: "bob", Prefix: "alice/", Permission: PermWrite},
+		},
+	}))
+	defer func() {
+		ClientToken = ""
+		servers.Stop()
+	}()
+
+	ClientToken = "t-alice"
+	id := &PaxosInstanceId{Key: "alice/k", Ver: 0}
+	// the value of p1 is voted by Acceptor-0 only, so that p2 votes it again
+	p1 := Proposer{Id: id, Bal: &BallotNum{N: 1, ProposerId: 1}, Val: &Value{Vi64: 1}}
+	_, _, err := p1.Phase1(acceptorIds, 3)
+	r.Nil(err)
+	_, err = p1.Phase2(acceptorIds[:1], 1)
+	r.Nil(err)
+
+	// a lower ballot changes nothing, a higher one changes LastBal and VBal
+	ClientToken = "t-bob"
+	p0 := Proposer{Id: id, Bal: &BallotNum{N: 0, ProposerId: 2}}
+	_, _, err = p0.Phase1(acceptorIds, 2)
+	r.NotNil(err)
+	p2 := Proposer{Id: id, Bal: &BallotNum{N: 2, ProposerId: 2}}
+	_, err = p2.Propose(context.Background(), acceptorIds, &Value{Vi64: 2})
+	r.Nil(err)
+
+	_, err = AuditOf(0, "", "alice/k", 0)
+	r.Equal(ErrPermissionDenied.Error(), err.Error())
+
+	ClientToken = "t-alice"
+	records, err := AuditOf(0, "", "alice/k", 0)
+	r.Nil(err)
+	r.Len(records, 4)
+	var methods []string
+	for _, record := range records {
+		methods = append(methods, record.Method)
+		r.Equal(int64(0), record.AcceptorId)
+		r.NotEmpty(record.Peer)
+		r.NotZero(record.Time)
+	}
+	r.Equal([]string{"Prepare", "Accept", "Prepare", "Accept"}, methods)
+	r.Equal("alice", records[0].Caller)
+	r.Equal("bob", records[2].Caller)
+	r.Equal(int64(0), records[0].Old.LastBal.N)
+	r.Equal(int64(1), records[0].New.LastBal.N)
+	r.Nil(records[1].Old.Val)
+	r.Equal(int64(1), records[1].New.Val.Vi64)
+	r.Equal(int64(1), records[3].Old.VBal.N)
+	r.Equal(int64(2), records[3].New.VBal.N)
+	r.Equal(int64(1), records[3].New.Val.Vi64)
+
+	r.Eventually(func() bool {
+		return strings.Count(out.String(), "\n") == 10
+	}, time.Second, 10*time.Millisecond)
+	r.Contains(out.String(), `"Method":"Accept"`)
+}
+
+func TestAudit_CollectGarbage(t *testing.T) {
+	r := require.New(t)
+
+	s := NewKVServer(WithAudit(nil))
+	ctx := context.Background()
+	for ver := int64(0); ver < 2; ver++ {
+		req := &Proposer{Id: &PaxosInstanceId{Key: "k", Ver: ver}, Bal: &BallotNum{N: 1}, Val: &Value{Vi64: ver}}
+		for _, phase := range []func(context.Context, *Proposer) (*Acceptor, error){s.Prepare, s.Accept, s.Commit} {
+			_, err := phase(ctx, req)
+			r.Nil(err)
+		}
+	}
+
+	reply, err := s.Audit(ctx, &AuditRequest{Key: "k"})
+	r.Nil(err)
+	r.Len(reply.Records, 4)
+	reply, err = s.Audit(ctx, &AuditRequest{Key: "k", FromVer: 1})
+	r.Nil(err)
+	r.Len(reply.Records, 2)
+
+	s.CollectGarbage()
+	reply, err = s.Audit(ctx, &AuditRequest{Key: "k"})
+	r.Nil(err)
+	r.Len(reply.Records, 2)
+	r.Equal(int64(1), reply.Records[0].Id.Ver)
+}
+
+func TestAudit_MaxRecords(t *testing.T) {
+	r := require.New(t)
+
+	defer func(n int) { MaxAuditRecords = n }(MaxAuditRecords)
+	MaxAuditRecords = 3
+
+	out := &syncBuffer{}
+	s := NewKVServer(WithAudit(out))
+	stop := s.startAudit()
+	ctx := context.Background()
+	for n := int64(1); n <= 3; n++ {
+		req := &Proposer{Id: &PaxosInstanceId{Key: "k"}, Bal: &BallotNum{N: n}, Val: &Value{Vi64: n}}
+		for _, phase := range []func(context.Context, *Proposer) (*Acceptor, error){s.Prepare, s.Accept} {
+			_, err := phase(ctx, req)
+			r.Nil(err)
+		}
+	}
+
+	reply, err := s.Audit(ctx, &AuditRequest{Key: "k"})
+	r.Nil(err)
+	r.Len(reply.Records, 3)
+	r.Equal(int64(3), reply.Records[2].New.VBal.N)
+	r.Equal(int64(2), reply.Records[0].New.VBal.N)
+
+	// every record is written out once the writer stops
+	stop()
+	stop()
+	r.Equal(6, strings.Count(out.String(), "\n"))
+}
+
+func TestAudit_Dropped(t *testing.T) {
+	r := require.New(t)
+
+	defer func(n int) { AuditQueueSize = n }(AuditQueueSize)
+	AuditQueueSize = 2
+
+	// no writer runs, the records are held in the queue until flushed
+	out := &syncBuffer{}
+	s := NewKVServer(WithAudit(out))
+	dropped := testutil.ToFloat64(auditRecordsDropped)
+	ctx := context.Background()
+	for n := int64(1); n <= 3; n++ {
+		req := &Proposer{Id: &PaxosInstanceId{Key: "k"}, Bal: &BallotNum{N: n}, Val: &Value{Vi64: n}}
+		for _, phase := range []func(context.Context, *Proposer) (*Acceptor, error){s.Prepare, s.Accept} {
+			_, err := phase(ctx, req)
+			r.Nil(err)
+		}
+	}
+	r.Empty(out.String())
+	r.Equal(float64(4), testutil.ToFloat64(auditRecordsDropped)-dropped)
+
+	// the records dropped are marked in the output
+	r.Nil(s.flush())
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	r.Len(lines, 3)
+	r.Contains(lines[0], `"Method":"Prepare"`)
+	r.Contains(lines[1], `"Method":"Accept"`)
+	r.Contains(lines[2], `"Dropped":4`)
+
+	stop := s.startAudit()
+	defer stop()
+	req := &Proposer{Id: &PaxosInstanceId{Key: "k"}, Bal: &BallotNum{N: 4}}
+	_, err := s.Prepare(ctx, req)
+	r.Nil(err)
+	r.Nil(s.flush())
+	r.Equal(4, strings.Count(out.String(), "\n"))
+}
@@ -0,0 +1,271 @@
+package core
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+var (
+	ErrUnauthenticated  = status.Error(codes.Unauthenticated, "caller is not authenticated")
+	ErrPermissionDenied = status.Error(codes.PermissionDenied, "permission denied")
+)
+
+// ClientToken is the bearer token Proposers, Learners and watchers present to
+// Acceptors, empty for none. A token is never sent in the clear, dialing fails
+// unless ClientTLS is set too.
+var ClientToken string
+
+// Permission is the access granted on keys, a higher permission implies the
+// lower ones.
+type Permission int
+
+const (
+	PermRead Permission = iota + 1
+	PermWrite
+	PermAdmin
+)
+
+// Rule grants a principal a permission on the keys with a prefix in a namespace.
+type Rule struct {
+	// Principal is the caller granted, "*" for any authenticated caller.
+	Principal  string
+	Namespace  string
+	Prefix     string
+	Permission Permission
+}
+
+// AccessControl authenticates the callers of an Acceptor and authorizes their
+// requests against the rules.
+//
+// A caller is identified by the bearer token it presents, or else by the common
+// name of its verified client certificate if the Acceptor serves mutual TLS.
+//
+// The requests are authorized as follows:
+//   - LatestVersion, Watch and Scan require PermRead on the keys.
+//   - Prepare requires PermRead on the key of the instance, or PermWrite if
+//     it carries a value.
+//   - Accept votes a value, it requires PermWrite on the key of the instance.
+//   - Commit only records a value the Acceptor has voted at the same ballot,
+//     it requires PermRead on the key of the instance.
+//   - NamespaceStats requires PermAdmin on the whole namespace.
+//   - Audit requires PermAdmin on the key.
+//   - Subscribe streams every namespace, it is allowed to privileged callers only.
+//   - LogLevel changes the whole process, it is allowed to privileged callers only.
+//   - Status reports the whole Acceptor, it is allowed to privileged callers only.
+//   - Fence rejects the Proposers of stale epochs on all keys, it is allowed to
+//     privileged callers only.
+//   - Health checks are allowed to anyone, even unauthenticated, so that
+//     orchestrators can probe without credentials.
+//
+// Privileged callers, e.g. trusted Proposers and Learners serving others, may
+// call the paxos RPCs Prepare, Accept and Commit on any key, Subscribe,
+// LogLevel, Status and Fence.
+// They are authorized by the rules for the other requests.
+//
+// A Client reads a key with Prepare and Commit only, as long as the version
+// read has been voted by a quorum at one ballot, see Proposer.Propose, so
+// PermRead is enough to read. Finishing a version whose write is still in
+// progress takes an Accept, which is left to the writers.
+//
+// An Accept of a caller not privileged is taken only at the ballot the same
+// caller has prepared on the Acceptor, see KVServer.Accept: a raw Accept
+// skipping phase-1, or reusing the ballot of another Proposer to vote a
+// different value on it, is rejected with ErrPermissionDenied. So is a
+// Prepare at a ballot another caller has prepared, callers must not share
+// ProposerIds. A caller with PermWrite still runs paxos itself, grant it only
+// to callers trusted to follow the protocol, or route the others through a
+// privileged Proposer.
+type AccessControl struct {
+	// Tokens maps bearer tokens to principals.
+	Tokens     map[string]string
+	Rules      []Rule
+	Privileged []string
+}
+
+// WithAccessControl authenticates and authorizes the requests to the acceptors.
+func WithAccessControl(ac *AccessControl) ServerOption {
+	return func(s *KVServer) {
+		s.access = ac
+	}
+}
+
+// UnaryInterceptor authorizes unary requests.
+func (ac *AccessControl) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := ac.authorize(ctx, info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor authorizes streaming requests on receiving the request.
+func (ac *AccessControl) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &authorizedStream{ServerStream: ss, ac: ac, method: info.FullMethod})
+}
+
+type authorizedStream struct {
+	grpc.ServerStream
+	ac     *AccessControl
+	method string
+}
+
+func (s *authorizedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.ac.authorize(s.Context(), s.method, m)
+}
+
+// authorize checks the caller of the context is allowed to make the request.
+func (ac *AccessControl) authorize(ctx context.Context, method string, req interface{}) error {
+	if _, ok := req.(*grpc_health_v1.HealthCheckRequest); ok {
+		return nil
+	}
+
+	principal, ok := ac.authenticate(ctx)
+	if !ok {
+		acceptorLog.Warnf("Acceptor: unauthenticated request to %s", method)
+		return ErrUnauthenticated
+	}
+
+	var allowed bool
+	switch r := req.(type) {
+	case *Proposer:
+		perm := PermRead
+		if strings.HasSuffix(method, "/Accept") || strings.HasSuffix(method, "/Prepare") && r.Val != nil {
+			perm = PermWrite
+		}
+		allowed = ac.privileged(principal) || ac.allowed(principal, r.Id.GetNamespace(), perm, func(prefix string) bool {
+			return strings.HasPrefix(r.Id.GetKey(), prefix)
+		})
+	case *SubscribeRequest, *LogLevelRequest, *StatusRequest, *FenceRequest:
+		allowed = ac.privileged(principal)
+	case *LatestVersionRequest:
+		allowed = ac.allowed(principal, r.Namespace, PermRead, func(prefix string) bool {
+			return strings.HasPrefix(r.Key, prefix)
+		})
+	case *WatchRequest:
+		allowed = ac.allowed(principal, r.Namespace, PermRead, func(prefix string) bool {
+			return strings.HasPrefix(r.Key, prefix)
+		})
+	case *ScanRequest:
+		allowed = ac.allowed(principal, r.Namespace, PermRead, func(prefix string) bool {
+			return coversRange(prefix, r.StartKey, r.EndKey)
+		})
+	case *AuditRequest:
+		allowed = ac.allowed(principal, r.Namespace, PermAdmin, func(prefix string) bool {
+			return strings.HasPrefix(r.Key, prefix)
+		})
+	case *NamespaceStatsRequest:
+		allowed = ac.allowed(principal, r.Namespace, PermAdmin, func(prefix string) bool {
+			return prefix == ""
+		})
+	}
+
+	if !allowed {
+		namespace, key := requestKey(req)
+		acceptorLog.Warnf("Acceptor: %s denied to %s on key %q of namespace %q", principal, method, key, namespace)
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// authenticate returns the principal of the caller of the context.
+func (ac *AccessControl) authenticate(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		if token := strings.TrimPrefix(auth, "Bearer "); token != auth {
+			principal, ok := ac.Tokens[token]
+			return principal, ok
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+			return info.State.VerifiedChains[0][0].Subject.CommonName, true
+		}
+	}
+	return "", false
+}
+
+// caller returns the principal of the caller of the context, and whether it is
+// privileged. Every caller is privileged without access control.
+func (s *KVServer) caller(ctx context.Context) (string, bool) {
+	if s.access == nil {
+		return "", true
+	}
+	principal, _ := s.access.authenticate(ctx)
+	return principal, s.access.privileged(principal)
+}
+
+func (ac *AccessControl) privileged(principal string) bool {
+	for _, p := range ac.Privileged {
+		if p == principal {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether a rule grants the principal the permission in the
+// namespace on a prefix matching the request.
+func (ac *AccessControl) allowed(principal, namespace string, perm Permission, match func(prefix string) bool) bool {
+	for _, rule := range ac.Rules {
+		if (rule.Principal == principal || rule.Principal == "*") && rule.Namespace == namespace &&
+			rule.Permission >= perm && match(rule.Prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestKey returns the namespace and the key a request is on, the start key
+// for a Scan, so that denials are logged without the values in requests.
+func requestKey(req interface{}) (string, string) {
+	switch r := req.(type) {
+	case *Proposer:
+		return r.Id.GetNamespace(), r.Id.GetKey()
+	case *LatestVersionRequest:
+		return r.Namespace, r.Key
+	case *WatchRequest:
+		return r.Namespace, r.Key
+	case *ScanRequest:
+		return r.Namespace, r.StartKey
+	case *AuditRequest:
+		return r.Namespace, r.Key
+	case *NamespaceStatsRequest:
+		return r.Namespace, ""
+	}
+	return "", ""
+}
+
+// coversRange reports whether all the keys in [startKey, endKey) have the prefix.
+func coversRange(prefix, startKey, endKey string) bool {
+	if !strings.HasPrefix(startKey, prefix) {
+		return false
+	}
+	end := prefixEnd(prefix)
+	return end == "" || endKey != "" && endKey <= end
+}
+
+// tokenCredentials presents a bearer token on every RPC.
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity requires TLS, so that the token is never sent in the clear.
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestAccessControl(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds, withTestTLS(t, r), WithAccessControl(&AccessControl{
+		Tokens: map[string]string{"t-alice": "alice", "t-bob": "bob", "t-proxy": "proxy"},
+		Rules: []Rule{
+			{Principal: "alice", Prefix: "alice/", Permission: PermWrite},
+			{Principal: "bob", Prefix: "alice/", Permission: PermRead},
+			{Principal: "*", Namespace: "public", Permission: PermAdmin},
+		},
+		Privileged: []string{"proxy"},
+	}))
+	defer func() {
+		ClientToken = ""
+		servers.Stop()
+	}()
+
+	client := NewClient(acceptorIds, 1)
+
+	_, err := client.Set("alice/k", &Value{Vi64: 1})
+	r.Equal(ErrUnauthenticated, err)
+
+	// tokens are never sent in the clear
+	ClientToken = "t-alice"
+	tlsConfig := ClientTLS
+	ClientTLS = nil
+	_, err = dialAcceptor(0)
+	r.NotNil(err)
+	ClientTLS = tlsConfig
+
+	_, err = client.Set("alice/k", &Value{Vi64: 1})
+	r.Nil(err)
+	_, err = client.Set("bob/k", &Value{Vi64: 1})
+	r.Equal(ErrPermissionDenied, err)
+	_, err = NamespaceStatsOf(0, "")
+	r.Equal(ErrPermissionDenied.Error(), err.Error())
+
+	// a writer cannot vote without preparing the ballot itself
+	raw := &Proposer{Id: &PaxosInstanceId{Key: "alice/k", Ver: 0}, Bal: &BallotNum{N: 9, ProposerId: 1}, Val: &Value{Vi64: 9}}
+	_, err = grpcTransport{}.Call(context.Background(), 0, "Accept", raw)
+	r.Equal(codes.PermissionDenied, status.Code(err))
+
+	// bob may read the keys of alice, but not write them
+	ClientToken = "t-bob"
+	bob := NewClient(acceptorIds, 2)
+	val, _, err := bob.Get("alice/k")
+	r.Nil(err)
+	r.Equal(int64(1), val.Vi64)
+	reply, err := bob.ListPrefix(0, "alice/", 0, "")
+	r.Nil(err)
+	r.Len(reply.Instances, 1)
+	_, err = bob.Scan(0, "", "", 0, "")
+	r.Equal(ErrPermissionDenied.Error(), err.Error())
+	_, err = bob.Set("alice/k", &Value{Vi64: 2})
+	r.Equal(ErrPermissionDenied, err)
+
+	// nor vote on the ballot prepared by alice
+	ClientToken = "t-alice"
+	raw.Val = nil
+	_, err = grpcTransport{}.Call(context.Background(), 0, "Prepare", raw)
+	r.Nil(err)
+	ClientToken = "t-bob"
+	_, err = grpcTransport{}.Call(context.Background(), 0, "Prepare", raw)
+	r.Equal(codes.PermissionDenied, status.Code(err))
+	raw.Val = &Value{Vi64: 9}
+	_, err = grpcTransport{}.Call(context.Background(), 0, "Accept", raw)
+	r.Equal(codes.PermissionDenied, status.Code(err))
+
+	// privileged callers run paxos on any key
+	ClientToken = "t-proxy"
+	_, err = client.Set("bob/k", &Value{Vi64: 2})
+	r.Equal(ErrPermissionDenied, err)
+	p := Proposer{Id: &PaxosInstanceId{Key: "bob/k", Ver: 0}, Bal: &BallotNum{ProposerId: 1}}
+	val, err = p.Propose(context.Background(), acceptorIds, &Value{Vi64: 2})
+	r.Nil(err)
+	r.Equal(int64(2), val.Vi64)
+
+	// any authenticated caller is granted on the public namespace
+	public := &Client{AcceptorIds: acceptorIds, ProposerId: 1, Namespace: "public"}
+	_, err = public.Set("k", &Value{Vi64: 3})
+	r.Nil(err)
+	stats, err := NamespaceStatsOf(0, "public")
+	r.Nil(err)
+	r.Equal(int64(1), stats.Keys)
+}
+
+func TestCoversRange(t *testing.T) {
+	r := require.New(t)
+
+	r.True(coversRange("", "", ""))
+	r.True(coversRange("a/", "a/", "a0"))
+	r.True(coversRange("a/", "a/x", "a/y"))
+	r.False(coversRange("a/", "a/", ""))
+	r.False(coversRange("a/", "a/", "b"))
+	r.False(coversRange("a/", "", "a0"))
+}
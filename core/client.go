@@ -0,0 +1,187 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+var ErrNotFound = errors.New("key not found")
+
+// Client reads and writes records on a group of Acceptors.
+// Every version of a record is established by running paxos on it.
+type Client struct {
+	AcceptorIds []int64
+	ProposerId  int64
+	// Namespace is the namespace the records are in, empty for the default one.
+	// Records of different namespaces are isolated from each other.
+	Namespace string
+	// Now is the clock expiration is decided by, time.Now if nil.
+	Now func() time.Time
+	// Epoch is the epoch of the shard map the Client routes by, Acceptors
+	// fenced at a higher epoch reject its requests with ErrStaleEpoch.
+	Epoch int64
+	// Transport carries the requests of the Client, gRPC if nil. Watch always
+	// streams over gRPC.
+	Transport Transport
+}
+
+// NewClient creates a Client running paxos on the specified Acceptors.
+func NewClient(acceptorIds []int64, proposerId int64) *Client {
+	return &Client{AcceptorIds: acceptorIds, ProposerId: proposerId}
+}
+
+// Get returns the value of the latest chosen version of the key and the version.
+// It returns ErrNotFound if the key does not exist, has been deleted or expired.
+// Intents of transactions are resolved, see Txn.
+//
+// The returned version is the one the result is observed at, even along with
+// ErrNotFound. Writing the next version of it with CompareAndSet succeeds only
+// if the key has not been changed since.
+func (c *Client) Get(key string) (*Value, int64, error) {
+	return c.GetContext(context.Background(), key)
+}
+
+// GetContext is Get giving up once ctx is done, it returns the error of ctx then.
+func (c *Client) GetContext(ctx context.Context, key string) (*Value, int64, error) {
+	latest, floor, err := quorumVersions(ctx, c.transport(), c.AcceptorIds, c.Namespace, key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	val, observed, err := c.resolve(ctx, key, latest, floor)
+	if err != nil {
+		return nil, 0, err
+	}
+	if val == nil || val.Tombstone || val.Expired(c.now()) {
+		return nil, observed, ErrNotFound
+	}
+	return val, observed, nil
+}
+
+// resolve returns the value in effect of the key at version `from`: the chosen
+// value of the highest version from `from` down to floor, skipping the
+// versions not chosen and the intents of transactions not committed. It also
+// returns the highest version chosen, which the result is observed at, or
+// floor - 1 if there is none. The value is nil if no version is in effect, it
+// may be a tombstone or have expired.
+func (c *Client) resolve(ctx context.Context, key string, from, floor int64) (*Value, int64, error) {
+	observed := floor - 1
+	// The latest voted version may not be chosen, fall back to earlier ones.
+	for ver := from; ver >= floor; ver-- {
+		val, err := c.propose(ctx, key, ver, nil)
+		if err != nil {
+			return nil, 0, err
+		}
+		if val == nil {
+			continue
+		}
+		if observed < ver {
+			observed = ver
+		}
+
+		// The intent of an aborted transaction does not take effect.
+		if val.TxnId != "" {
+			committed, err := c.txnCommitted(ctx, val.TxnId)
+			if err != nil {
+				return nil, 0, err
+			}
+			if !committed {
+				continue
+			}
+		}
+		return val, observed, nil
+	}
+
+	return nil, observed, nil
+}
+
+// Set writes the value as the next version of the key and returns the version.
+// It returns ErrQuotaExceeded if the write exceeds the quota of the namespace.
+func (c *Client) Set(key string, val *Value) (int64, error) {
+	return c.SetContext(context.Background(), key, val)
+}
+
+// SetContext is Set giving up once ctx is done, it returns the error of ctx then.
+// The value may still be chosen on a version after it gives up.
+func (c *Client) SetContext(ctx context.Context, key string, val *Value) (int64, error) {
+	for {
+		ver, err := nextVersion(ctx, c.transport(), c.AcceptorIds, c.Namespace, key)
+		if err != nil {
+			return 0, err
+		}
+
+		// Another value may be chosen on the version, retry on the next one.
+		chosen, err := c.propose(ctx, key, ver, val)
+		if err != nil {
+			return 0, err
+		}
+		if proto.Equal(chosen, val) {
+			return ver, nil
+		}
+	}
+}
+
+// Read returns the chosen value of the specified version of the key regardless
+// of tombstone or expiration, or nil if no value has been chosen on it.
+func (c *Client) Read(key string, ver int64) *Value {
+	val, _ := c.propose(context.Background(), key, ver, nil)
+	return val
+}
+
+// CompareAndSet writes the value as the specified version of the key.
+// It returns false if another value has been chosen on the version, or the
+// Acceptors reject the write, e.g. the quota of the namespace is exceeded.
+func (c *Client) CompareAndSet(key string, ver int64, val *Value) bool {
+	ok, _ := c.CompareAndSetContext(context.Background(), key, ver, val)
+	return ok
+}
+
+// CompareAndSetContext is CompareAndSet giving up once ctx is done. Along with
+// false, it returns the error for which the write is rejected, e.g.
+// ErrQuotaExceeded or the error of ctx, nil if another value has been chosen.
+func (c *Client) CompareAndSetContext(ctx context.Context, key string, ver int64, val *Value) (bool, error) {
+	chosen, err := c.propose(ctx, key, ver, val)
+	if err != nil {
+		return false, err
+	}
+	return proto.Equal(chosen, val), nil
+}
+
+// Delete writes a tombstone as the next version of the key and returns the version.
+func (c *Client) Delete(key string) (int64, error) {
+	return c.Set(key, &Value{Tombstone: true})
+}
+
+// DeleteContext is Delete giving up once ctx is done, see SetContext.
+func (c *Client) DeleteContext(ctx context.Context, key string) (int64, error) {
+	return c.SetContext(ctx, key, &Value{Tombstone: true})
+}
+
+// now returns the current time of the clock of the Client.
+func (c *Client) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// transport returns the Transport of the Client.
+func (c *Client) transport() Transport {
+	return OrGRPC(c.Transport)
+}
+
+// propose runs paxos on the version of the key, see Proposer.Propose.
+func (c *Client) propose(ctx context.Context, key string, ver int64, val *Value) (*Value, error) {
+	p := Proposer{
+		Id:    &PaxosInstanceId{Key: key, Ver: ver, Namespace: c.Namespace},
+		Bal:   &BallotNum{N: 0, ProposerId: c.ProposerId},
+		Epoch: c.Epoch,
+	}
+	return p.ProposeWith(ctx, c.transport(), c.AcceptorIds, val)
+}
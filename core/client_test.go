@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestClient_SetGetDelete(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	client := NewClient(acceptorIds, 2)
+
+	_, _, err := client.Get("k")
+	r.Equal(ErrNotFound, err)
+
+	ver, err := client.Set("k", &Value{Vi64: 5})
+	r.Nil(err)
+	r.Equal(int64(0), ver)
+
+	ver, err = client.Set("k", &Value{Vi64: 6})
+	r.Nil(err)
+	r.Equal(int64(1), ver)
+
+	val, ver, err := client.Get("k")
+	r.Nil(err)
+	r.Equal(int64(1), ver)
+	r.Equal(int64(6), val.Vi64)
+
+	ver, err = client.Delete("k")
+	r.Nil(err)
+	r.Equal(int64(2), ver)
+
+	_, _, err = client.Get("k")
+	r.Equal(ErrNotFound, err)
+
+	reply, err := client.ListPrefix(0, "k", 0, "")
+	r.Nil(err)
+	r.Empty(reply.Instances)
+
+	// a deleted key can be written again
+	ver, err = client.Set("k", &Value{Vi64: 7})
+	r.Nil(err)
+	r.Equal(int64(3), ver)
+}
+
+func TestClient_Context(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	faults := NewFaults(1)
+	client := NewClient(acceptorIds, 2)
+	client.Transport = faults.Transport(nil)
+
+	ver, err := client.SetContext(context.Background(), "k", &Value{Vi64: 5})
+	r.Nil(err)
+	ok, err := client.CompareAndSetContext(context.Background(), "k", ver, &Value{Vi64: 6})
+	r.Nil(err)
+	r.False(ok)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = client.GetContext(canceled, "k")
+	r.Equal(context.Canceled, err)
+
+	// paxos keeps retrying without a quorum until ctx is done
+	faults.Partition(1, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ok, err = client.CompareAndSetContext(ctx, "k", ver+1, &Value{Vi64: 6})
+	r.False(ok)
+	r.Equal(context.DeadlineExceeded, err)
+}
@@ -0,0 +1,233 @@
+package core
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+var (
+	ErrFaultDropped     = status.Error(codes.Unavailable, "fault: message dropped")
+	ErrFaultPartitioned = status.Error(codes.Unavailable, "fault: acceptor partitioned")
+)
+
+// Fault is the faults of the link to an Acceptor.
+type Fault struct {
+	// Partitioned fails every request to the Acceptor.
+	Partitioned bool
+	// Delay is the latency added to every request.
+	Delay time.Duration
+	// Drop is the probability a request is lost before reaching the Acceptor.
+	Drop float64
+	// DropReply is the probability a reply is lost after the request is handled.
+	DropReply float64
+	// Duplicate is the probability a unary request is handled twice.
+	Duplicate float64
+}
+
+// Faults are the faults of the links to Acceptors, which can be changed while
+// requests are sent. Lost messages fail with codes.Unavailable at once rather
+// than time out, so that tests run fast.
+//
+// Faults are injected into the requests of a Client through its Transport,
+// see Faults.Transport, or into the connections dialed by the caller with
+// the interceptors. They are meant for tests against real gRPC servers.
+type Faults struct {
+	mu    sync.Mutex
+	rng   *rand.Rand
+	links map[int64]Fault
+}
+
+// NewFaults creates Faults with healthy links, the random faults are chosen
+// from the seed.
+func NewFaults(seed int64) *Faults {
+	return &Faults{rng: rand.New(rand.NewSource(seed)), links: map[int64]Fault{}}
+}
+
+// Set sets the faults of the links to the Acceptors.
+func (f *Faults) Set(fault Fault, acceptorIds ...int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, aid := range acceptorIds {
+		f.links[aid] = fault
+	}
+}
+
+// Partition cuts off the links to the Acceptors.
+func (f *Faults) Partition(acceptorIds ...int64) {
+	f.Set(Fault{Partitioned: true}, acceptorIds...)
+}
+
+// Heal clears the faults of the links to the Acceptors, or of all the links
+// if no Acceptor is specified.
+func (f *Faults) Heal(acceptorIds ...int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(acceptorIds) == 0 {
+		f.links = map[int64]Fault{}
+	}
+	for _, aid := range acceptorIds {
+		delete(f.links, aid)
+	}
+}
+
+// faultsOf returns the faults of a request to the Acceptor: whether the
+// request or its reply is lost, or it is duplicated.
+func (f *Faults) faultsOf(aid int64) (fault Fault, drop, dropReply, duplicate bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fault = f.links[aid]
+	drop = f.rng.Float64() < fault.Drop
+	dropReply = f.rng.Float64() < fault.DropReply
+	duplicate = f.rng.Float64() < fault.Duplicate
+	return
+}
+
+// Transport returns a Transport injecting the faults into the requests sent
+// through next, gRPC if nil. Set as the Transport of a Client, it scopes the
+// faults to the Client.
+func (f *Faults) Transport(next Transport) Transport {
+	return &faultyTransport{faults: f, next: OrGRPC(next)}
+}
+
+// faultyTransport injects the faults into the requests sent through next.
+type faultyTransport struct {
+	faults *Faults
+	next   Transport
+}
+
+func (t *faultyTransport) Call(ctx context.Context, aid int64, method string, req *Proposer) (reply *Acceptor, err error) {
+	err = t.inject(ctx, aid, func(first bool) error {
+		r, err := t.next.Call(ctx, aid, method, req)
+		if first {
+			reply = r
+		}
+		return err
+	})
+	return
+}
+
+func (t *faultyTransport) LatestVersion(ctx context.Context, aid int64, req *LatestVersionRequest) (reply *LatestVersionReply, err error) {
+	err = t.inject(ctx, aid, func(first bool) error {
+		r, err := t.next.LatestVersion(ctx, aid, req)
+		if first {
+			reply = r
+		}
+		return err
+	})
+	return
+}
+
+func (t *faultyTransport) Scan(ctx context.Context, aid int64, req *ScanRequest) (reply *ScanReply, err error) {
+	err = t.inject(ctx, aid, func(first bool) error {
+		r, err := t.next.Scan(ctx, aid, req)
+		if first {
+			reply = r
+		}
+		return err
+	})
+	return
+}
+
+func (t *faultyTransport) Fence(ctx context.Context, aid int64, req *FenceRequest) (reply *FenceReply, err error) {
+	err = t.inject(ctx, aid, func(first bool) error {
+		r, err := t.next.Fence(ctx, aid, req)
+		if first {
+			reply = r
+		}
+		return err
+	})
+	return
+}
+
+// inject sends a request with send, injecting the faults of the link to the
+// Acceptor. A duplicate is sent with first false and its reply discarded.
+func (t *faultyTransport) inject(ctx context.Context, aid int64, send func(first bool) error) error {
+	fault, drop, dropReply, duplicate := t.faults.faultsOf(aid)
+	if err := delay(ctx, fault); err != nil {
+		return err
+	}
+	if drop {
+		return ErrFaultDropped
+	}
+
+	err := send(true)
+	if duplicate {
+		_ = send(false)
+	}
+	if err == nil && dropReply {
+		return ErrFaultDropped
+	}
+	return err
+}
+
+// UnaryClientInterceptor injects the faults of the link to the Acceptor into
+// unary requests.
+func (f *Faults) UnaryClientInterceptor(aid int64) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		fault, drop, dropReply, duplicate := f.faultsOf(aid)
+		if err := delay(ctx, fault); err != nil {
+			return err
+		}
+		if drop {
+			return ErrFaultDropped
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if duplicate {
+			dup := proto.Clone(reply.(proto.Message))
+			proto.Reset(dup)
+			_ = invoker(ctx, method, req, dup, cc, opts...)
+		}
+		if err == nil && dropReply {
+			return ErrFaultDropped
+		}
+		return err
+	}
+}
+
+// StreamClientInterceptor injects the faults of the link to the Acceptor into
+// streaming requests when they are opened.
+func (f *Faults) StreamClientInterceptor(aid int64) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		fault, drop, _, _ := f.faultsOf(aid)
+		if err := delay(ctx, fault); err != nil {
+			return nil, err
+		}
+		if drop {
+			return nil, ErrFaultDropped
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// delay waits for the latency of the link, or fails if it is partitioned.
+func delay(ctx context.Context, fault Fault) error {
+	if fault.Partitioned {
+		return ErrFaultPartitioned
+	}
+	if fault.Delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(fault.Delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-timer.C:
+		return nil
+	}
+}
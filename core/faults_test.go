@@ -0,0 +1,119 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestFaults_Partition(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	faults := NewFaults(1)
+	client := NewClient(acceptorIds, 1)
+	client.Transport = faults.Transport(nil)
+
+	// a minority partitioned does not stop the majority
+	faults.Partition(0)
+	_, err := client.Set("k", &Value{Vi64: 1})
+	r.Nil(err)
+
+	faults.Partition(1)
+	_, err = client.Set("k", &Value{Vi64: 2})
+	r.Equal(ErrNoEnoughQuorum, err)
+
+	// Acceptor-0 learns nothing of version 0, the majority still has it
+	faults.Heal(1)
+	val, _, err := client.Get("k")
+	r.Nil(err)
+	r.Equal(int64(1), val.Vi64)
+
+	faults.Heal()
+	val, ver, err := client.Get("k")
+	r.Nil(err)
+	r.Equal(int64(1), val.Vi64)
+	r.Equal(int64(0), ver)
+}
+
+func TestFaults_ScopedToClient(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	faults := NewFaults(1)
+	faults.Partition(0, 1)
+	cut := NewClient(acceptorIds, 1)
+	cut.Transport = faults.Transport(nil)
+	healthy := NewClient(acceptorIds, 2)
+
+	_, err := cut.Set("k", &Value{Vi64: 1})
+	r.Equal(ErrNoEnoughQuorum, err)
+	_, err = healthy.Set("k", &Value{Vi64: 2})
+	r.Nil(err)
+	_, err = cut.Scan(0, "", "", 10, "")
+	r.Equal(ErrFaultPartitioned, err)
+	reply, err := healthy.Scan(0, "", "", 10, "")
+	r.Nil(err)
+	r.Len(reply.Instances, 1)
+}
+
+func TestFaults_LossyLinks(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	faults := NewFaults(1)
+	faults.Set(Fault{Delay: time.Millisecond, Drop: 0.2, DropReply: 0.2, Duplicate: 0.3}, acceptorIds...)
+	client := NewClient(acceptorIds, 1)
+	client.Transport = faults.Transport(nil)
+	for i := int64(1); i <= 10; i++ {
+		// a write may fail without a quorum of replies, retry it
+		val := &Value{Vi64: i}
+		r.Eventually(func() bool {
+			_, err := client.Set("k", val)
+			return err == nil
+		}, 5*time.Second, time.Millisecond)
+	}
+
+	faults.Heal()
+	val, _, err := client.Get("k")
+	r.Nil(err)
+	r.Equal(int64(10), val.Vi64)
+}
+
+func TestAcceptorServer_CrashRestart(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	client := NewClient(acceptorIds, 1)
+	_, err := client.Set("k", &Value{Vi64: 1})
+	r.Nil(err)
+
+	servers.Server(0).Stop()
+	servers.Server(1).Stop()
+	_, err = client.Set("k", &Value{Vi64: 2})
+	r.Equal(ErrNoEnoughQuorum, err)
+
+	// the acceptors recover with their votes
+	r.Nil(servers.Server(0).Restart())
+	r.Nil(servers.Server(1).Restart())
+	servers.Server(2).Stop()
+	val, _, err := client.Get("k")
+	r.Nil(err)
+	r.Equal(int64(1), val.Vi64)
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// ErrStaleEpoch rejects a Prepare or Accept of an epoch lower than the fence of the Acceptor.
+var ErrStaleEpoch = status.Error(codes.Aborted, "epoch is older than the fence")
+
+// Fence handles Fence request. The fence never goes down, the reply carries
+// the fence after the request.
+//
+// Once Fence returns, no Prepare or Accept of an epoch lower than the fence
+// is taken, including those in flight when it is called. The fence is of the
+// whole Acceptor, it rejects the requests of every key and namespace.
+func (s *KVServer) Fence(c context.Context, r *FenceRequest) (*FenceReply, error) {
+	acceptorLog.Infof("Acceptor: receive Fence request: %v", r)
+
+	s.fenceMu.Lock()
+	defer s.fenceMu.Unlock()
+
+	if r.Epoch > s.fence {
+		s.fence = r.Epoch
+	}
+	return &FenceReply{Epoch: s.fence}, nil
+}
+
+// admit checks a Prepare or Accept of the epoch against the fence, and holds
+// the fence until the returned func is called.
+func (s *KVServer) admit(epoch int64) (func(), error) {
+	s.fenceMu.RLock()
+	if epoch < s.fence {
+		s.fenceMu.RUnlock()
+		return nil, ErrStaleEpoch
+	}
+	return s.fenceMu.RUnlock, nil
+}
+
+// FenceAcceptor raises the fence of an Acceptor to the epoch, it returns the
+// fence of the Acceptor after the request.
+func FenceAcceptor(aid int64, epoch int64) (int64, error) {
+	reply, err := grpcTransport{}.Fence(context.Background(), aid, &FenceRequest{Epoch: epoch})
+	if err != nil {
+		return 0, err
+	}
+	return reply.Epoch, nil
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestAcceptor_Fence(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	client := NewClient(acceptorIds, 1)
+	_, err := client.Set("a", &Value{Vi64: 1})
+	r.Nil(err)
+
+	for _, aid := range acceptorIds {
+		fence, err := FenceAcceptor(aid, 2)
+		r.Nil(err)
+		r.Equal(int64(2), fence)
+	}
+
+	// the fence never goes down
+	fence, err := FenceAcceptor(0, 1)
+	r.Nil(err)
+	r.Equal(int64(2), fence)
+
+	// requests of a stale epoch are rejected, reading versions is not
+	_, err = client.Set("a", &Value{Vi64: 2})
+	r.Equal(ErrStaleEpoch, err)
+	_, _, err = client.Get("a")
+	r.Equal(ErrStaleEpoch, err)
+	latest, err := LatestVersion(acceptorIds, "a")
+	r.Nil(err)
+	r.Equal(int64(0), latest)
+
+	client.Epoch = 2
+	ver, err := client.Set("a", &Value{Vi64: 2})
+	r.Nil(err)
+	r.Equal(int64(1), ver)
+}
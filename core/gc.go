@@ -0,0 +1,183 @@
+package core
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// FloorRetention is the time an Acceptor remembers the floor of a key
+// reclaimed entirely for. A request on a reclaimed version of the key delayed
+// by more is taken as one on a new key.
+var FloorRetention = time.Minute
+
+// CollectGarbage reclaims the versions superseded by a later committed version
+// in effect, and reclaims a key entirely if its latest committed version in
+// effect is a tombstone. It returns the number of reclaimed versions.
+//
+// An intent of a transaction is in effect only if the Acceptor has committed
+// the record of the transaction as committed, so that the versions a reader
+// falls back to from an aborted or pending intent are kept.
+//
+// The Acceptor remembers the lowest version not reclaimed of a key, and rejects
+// requests on reclaimed versions with ErrCompacted, so that no value could be
+// voted on them again, for FloorRetention if the key is reclaimed entirely.
+// The instances and the audit records of the reclaimed versions are dropped.
+func (s *KVServer) CollectGarbage() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reclaimed int
+	for _, key := range append([]string(nil), s.keys...) {
+		versions := s.Storage[key]
+		latest := s.latestInEffect(key)
+		if latest == nil {
+			continue
+		}
+
+		floor := latest.Id.Ver
+		if latest.Val.Tombstone {
+			floor += 1
+		}
+
+		namespace, _ := splitStorageKey(key)
+		for ver, v := range versions {
+			if ver < floor {
+				v.mu.Lock()
+				s.useBytes(namespace, -versionBytes(v))
+				v.mu.Unlock()
+				s.useVersions(namespace, -1)
+
+				delete(versions, ver)
+				reclaimed += 1
+			}
+		}
+
+		if s.floors == nil {
+			s.floors = map[string]int64{}
+		}
+		s.floors[key] = floor
+		s.pruneAudit(key, floor)
+
+		if len(versions) == 0 {
+			delete(s.Storage, key)
+			s.unindexKey(key)
+			s.releaseKey(splitStorageKey(key))
+			if s.reclaimedAt == nil {
+				s.reclaimedAt = map[string]time.Time{}
+			}
+			s.reclaimedAt[key] = time.Now()
+		}
+	}
+
+	dropped := s.compactCommitLog()
+	s.dropFloors()
+
+	if reclaimed > 0 {
+		acceptorLog.Infof("Acceptor: garbage collected, %d versions reclaimed, %d instances dropped from the commit log",
+			reclaimed, dropped)
+	}
+	return reclaimed
+}
+
+// WithGC collects garbage on every acceptor every interval while it serves,
+// so that superseded versions and their audit records do not pile up.
+func WithGC(interval time.Duration) ServerOption {
+	return func(s *KVServer) {
+		s.gcInterval = interval
+	}
+}
+
+// startGC collects garbage every interval of WithGC in the background, and
+// returns a function stopping it, which may be called more than once.
+func (s *KVServer) startGC() func() {
+	if s.gcInterval <= 0 {
+		return func() {}
+	}
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.gcInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.CollectGarbage()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stop) })
+		<-done
+	}
+}
+
+// dropFloors forgets the floors of the keys reclaimed entirely FloorRetention
+// ago and not written since. s.mu must be held.
+func (s *KVServer) dropFloors() {
+	for key, at := range s.reclaimedAt {
+		if _, ok := s.Storage[key]; ok {
+			delete(s.reclaimedAt, key)
+			continue
+		}
+		if time.Since(at) >= FloorRetention {
+			delete(s.floors, key)
+			delete(s.reclaimedAt, key)
+		}
+	}
+}
+
+// latestInEffect returns the highest committed version of the key in effect,
+// see CollectGarbage, or nil if there is none. s.mu must be held.
+func (s *KVServer) latestInEffect(skey string) *Instance {
+	namespace, key := splitStorageKey(skey)
+
+	var committed []*Instance
+	for ver, v := range s.Storage[skey] {
+		v.mu.Lock()
+		if v.chosen != nil {
+			committed = append(committed, &Instance{
+				Id:  &PaxosInstanceId{Key: key, Ver: ver, Namespace: namespace},
+				Val: v.chosen,
+			})
+		}
+		v.mu.Unlock()
+	}
+	sort.Slice(committed, func(i, j int) bool { return committed[i].Id.Ver > committed[j].Id.Ver })
+
+	for _, inst := range committed {
+		if inst.Val.TxnId == "" || s.txnStatusLocked(namespace, inst.Val.TxnId) == TxnCommitted {
+			return inst
+		}
+	}
+	return nil
+}
+
+// txnStatusLocked returns the status of the transaction the Acceptor has
+// committed, or 0 if it has not. s.mu must be held.
+func (s *KVServer) txnStatusLocked(namespace, id string) int64 {
+	v, ok := s.Storage[storageKey(namespace, TxnRecordPrefix+id)][0]
+	if !ok {
+		return 0
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.chosen.GetVi64()
+}
+
+// unindexKey removes a key from the ordered index, s.mu must be held.
+func (s *KVServer) unindexKey(key string) {
+	i := sort.SearchStrings(s.keys, key)
+	if i < len(s.keys) && s.keys[i] == key {
+		s.keys = append(s.keys[:i], s.keys[i+1:]...)
+	}
+}
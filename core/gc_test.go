@@ -0,0 +1,117 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestAcceptor_CollectGarbage(t *testing.T) {
+	r := require.New(t)
+
+	s := &KVServer{Storage: map[string]Versions{}}
+	commit(s, "a", 0, 1)
+	commit(s, "a", 1, 2)
+	commit(s, "d", 0, 1)
+	tombstone := &Proposer{
+		Id:  &PaxosInstanceId{Key: "d", Ver: 1},
+		Bal: &BallotNum{N: 1},
+		Val: &Value{Tombstone: true},
+	}
+	_, _ = s.Accept(nil, tombstone)
+	_, _ = s.Commit(nil, tombstone)
+
+	r.Equal(3, s.CollectGarbage())
+
+	// superseded versions are reclaimed
+	r.Len(s.Storage["a"], 1)
+	_, err := s.Prepare(nil, &Proposer{Id: &PaxosInstanceId{Key: "a", Ver: 0}, Bal: &BallotNum{N: 2}})
+	r.Equal(ErrCompacted, err)
+
+	// deleted keys are reclaimed entirely
+	r.NotContains(s.Storage, "d")
+	r.Equal([]string{"a"}, s.keys)
+
+	reply, err := s.LatestVersion(nil, &LatestVersionRequest{Key: "d"})
+	r.Nil(err)
+	r.Equal(int64(-1), reply.VotedVer)
+	r.Equal(int64(2), reply.Floor)
+
+	_, err = s.Prepare(nil, &Proposer{Id: &PaxosInstanceId{Key: "d", Ver: 1}, Bal: &BallotNum{N: 2}})
+	r.Equal(ErrCompacted, err)
+	_, err = s.Prepare(nil, &Proposer{Id: &PaxosInstanceId{Key: "d", Ver: 2}, Bal: &BallotNum{N: 2}})
+	r.Nil(err)
+}
+
+func TestAcceptor_PeriodicGC(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds, WithGC(10*time.Millisecond), WithAudit(nil))
+	defer servers.Stop()
+
+	client := NewClient(acceptorIds, 1)
+	for i := int64(0); i < 3; i++ {
+		_, err := client.Set("k", &Value{Vi64: i})
+		r.Nil(err)
+	}
+
+	// only the latest version and its audit records are kept
+	kv := servers.KVServers()[0]
+	r.Eventually(func() bool {
+		states := kv.Snapshot()
+		return len(states) == 1 && states[0].Id.Ver == 2
+	}, 3*time.Second, 10*time.Millisecond)
+	records, err := kv.Audit(nil, &AuditRequest{Key: "k"})
+	r.Nil(err)
+	for _, record := range records.Records {
+		r.Equal(int64(2), record.Id.Ver)
+	}
+}
+
+func TestAcceptor_CompactCommitLog(t *testing.T) {
+	r := require.New(t)
+
+	defer func(d time.Duration) { FloorRetention = d }(FloorRetention)
+	FloorRetention = 0
+
+	s := NewKVServer()
+	commit(s, "a", 0, 1)
+	commit(s, "a", 1, 2)
+	commit(s, "d", 0, 1)
+	tombstone := &Proposer{
+		Id:  &PaxosInstanceId{Key: "d", Ver: 1},
+		Bal: &BallotNum{N: 1},
+		Val: &Value{Tombstone: true},
+	}
+	_, _ = s.Accept(nil, tombstone)
+	_, _ = s.Commit(nil, tombstone)
+	r.Equal(3, s.CollectGarbage())
+
+	// only the instance in effect is kept, at its position
+	instances, _, err := s.readCommitLog(1, false)
+	r.Nil(err)
+	r.Len(instances, 1)
+	r.Equal("a", instances[0].Id.Key)
+	r.Equal(int64(2), instances[0].Index)
+	status, err := s.Status(nil, &StatusRequest{})
+	r.Nil(err)
+	r.Equal(int64(4), status.LastIndex)
+
+	// a subscriber missing the compacted instances must start over
+	_, _, err = s.readCommitLog(3, true)
+	r.Equal(ErrLogCompacted, err)
+	instances, _, err = s.readCommitLog(5, true)
+	r.Nil(err)
+	r.Empty(instances)
+
+	// the floor of a key reclaimed entirely is forgotten after the retention
+	r.NotContains(s.floors, "d")
+	r.Equal(int64(1), s.floors["a"])
+	_, err = s.Prepare(nil, &Proposer{Id: &PaxosInstanceId{Key: "d", Ver: 0}, Bal: &BallotNum{N: 2}})
+	r.Nil(err)
+}
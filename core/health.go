@@ -0,0 +1,162 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// healthService is the name of the paxos service in the gRPC health service.
+const healthService = "core.PaxosKV"
+
+// setReady marks the Acceptor ready to serve paxos requests or not, and
+// reports it to the gRPC health service.
+//
+// Storage is in memory and there is no state to replay, an Acceptor is ready
+// once its gRPC server accepts connections.
+func (s *KVServer) setReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&s.ready, v)
+	if s.health == nil {
+		return
+	}
+
+	status := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	if ready {
+		status = grpc_health_v1.HealthCheckResponse_SERVING
+	}
+	s.health.SetServingStatus("", status)
+	s.health.SetServingStatus(healthService, status)
+}
+
+// isReady reports whether the Acceptor is ready to serve paxos requests.
+func (s *KVServer) isReady() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
+
+// newHealthServer creates the gRPC health service of the Acceptor, reporting
+// not serving until it is ready.
+func (s *KVServer) newHealthServer() *health.Server {
+	s.health = health.NewServer()
+	s.setReady(s.isReady())
+	return s.health
+}
+
+// WithHealth serves the liveness and readiness of every acceptor over HTTP on
+// /healthz and /readyz of port healthBasePort + acceptor id, whether or not
+// its metrics are served.
+func WithHealth(healthBasePort int) ServerOption {
+	return func(s *KVServer) {
+		s.healthBasePort = healthBasePort
+	}
+}
+
+// probeServer serves the liveness and readiness of an Acceptor over HTTP.
+//
+// The listener is closed along with the server, so that the port is free
+// once it is closed, even if Serve has not started yet.
+type probeServer struct {
+	*http.Server
+	listener net.Listener
+}
+
+func (p *probeServer) Close() error {
+	err := p.Server.Close()
+	_ = p.listener.Close()
+	return err
+}
+
+func (p *probeServer) Shutdown(ctx context.Context) error {
+	err := p.Server.Shutdown(ctx)
+	_ = p.listener.Close()
+	return err
+}
+
+// serveHealth serves the liveness and readiness of the Acceptor if enabled.
+func (s *KVServer) serveHealth() (*probeServer, error) {
+	if s.healthBasePort == 0 {
+		return nil, nil
+	}
+
+	addr := fmt.Sprintf(":%d", s.healthBasePort+int(s.id))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			acceptorLog.Errorf("Acceptor-%d: failed to serve health: %v", s.id, err)
+		}
+	}()
+	acceptorLog.Infof("Acceptor-%d is serving health on %s", s.id, addr)
+	return &probeServer{Server: server, listener: listener}, nil
+}
+
+// handleHealthz reports the process is alive.
+func (s *KVServer) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// handleReadyz reports whether the Acceptor is ready to serve paxos requests.
+func (s *KVServer) handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	if !s.isReady() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// Status handles Status request.
+func (s *KVServer) Status(c context.Context, r *StatusRequest) (*StatusReply, error) {
+	reply := &StatusReply{
+		AcceptorId: s.id,
+		Uptime:     int64(time.Since(s.created)),
+		Ready:      s.isReady(),
+	}
+
+	s.usageMu.Lock()
+	for _, u := range s.usages {
+		reply.Bytes += u.bytes
+		reply.Keys += u.keys
+		reply.Versions += u.versions
+	}
+	s.usageMu.Unlock()
+
+	s.logMu.Lock()
+	reply.LastIndex = s.lastIndex
+	s.logMu.Unlock()
+
+	return reply, nil
+}
+
+// StatusOf returns the status of an Acceptor.
+func StatusOf(aid int64) (*StatusReply, error) {
+	conn, err := dialAcceptor(aid)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	return NewPaxosKVClient(conn).Status(ctx, &StatusRequest{})
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestHealth(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds, WithHealth(9110), withTestTLS(t, r), WithAccessControl(&AccessControl{
+		Tokens:     map[string]string{"t-op": "op", "t-bob": "bob"},
+		Privileged: []string{"op"},
+	}))
+	defer func() {
+		ClientToken = ""
+		servers.Stop()
+	}()
+
+	// health checks need no credentials
+	conn, err := dialAcceptor(0)
+	r.Nil(err)
+	defer conn.Close()
+	for _, service := range []string{"", "core.PaxosKV"} {
+		reply, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(),
+			&grpc_health_v1.HealthCheckRequest{Service: service})
+		r.Nil(err)
+		r.Equal(grpc_health_v1.HealthCheckResponse_SERVING, reply.Status)
+	}
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		resp, err := http.Get("http://127.0.0.1:9110" + path)
+		r.Nil(err)
+		resp.Body.Close()
+		r.Equal(http.StatusOK, resp.StatusCode)
+	}
+	// the health is served without metrics
+	resp, err := http.Get("http://127.0.0.1:9110/metrics")
+	r.Nil(err)
+	resp.Body.Close()
+	r.Equal(http.StatusNotFound, resp.StatusCode)
+
+	ClientToken = "t-bob"
+	_, err = StatusOf(0)
+	r.Equal(ErrPermissionDenied.Error(), err.Error())
+
+	ClientToken = "t-op"
+	p := Proposer{Id: &PaxosInstanceId{Key: "k", Ver: 0}, Bal: &BallotNum{ProposerId: 1}}
+	_, err = p.Propose(context.Background(), acceptorIds, &Value{Vi64: 1})
+	r.Nil(err)
+	status, err := StatusOf(0)
+	r.Nil(err)
+	r.Equal(int64(0), status.AcceptorId)
+	r.True(status.Ready)
+	r.Positive(status.Uptime)
+	r.Equal(int64(1), status.Keys)
+	r.Equal(int64(1), status.Versions)
+	r.Positive(status.Bytes)
+	r.Equal(int64(1), status.LastIndex)
+}
+
+func TestHealth_NotReady(t *testing.T) {
+	r := require.New(t)
+
+	s := NewKVServer()
+	hs := s.newHealthServer()
+	reply, err := hs.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	r.Nil(err)
+	r.Equal(grpc_health_v1.HealthCheckResponse_NOT_SERVING, reply.Status)
+
+	status, err := s.Status(context.Background(), &StatusRequest{})
+	r.Nil(err)
+	r.False(status.Ready)
+}
+
+func TestHealth_ReadyOnceAccepting(t *testing.T) {
+	r := require.New(t)
+
+	servers := ServeAcceptors([]int64{0}, WithHealth(9130))
+	defer servers.Stop()
+
+	as := servers.Server(0)
+	r.True(as.kv.isReady())
+	as.Stop()
+	r.False(as.kv.isReady())
+
+	// a drained Acceptor is not reported ready by a late accept
+	as.kv.markReady()
+	r.False(as.kv.isReady())
+
+	r.Nil(as.Restart())
+	r.True(as.kv.isReady())
+	resp, err := http.Get("http://127.0.0.1:9130/readyz")
+	r.Nil(err)
+	resp.Body.Close()
+	r.Equal(http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,254 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// LearnerRetryInterval is the interval a Learner waits before re-subscribing to an Acceptor.
+var LearnerRetryInterval = 200 * time.Millisecond
+
+// errLearnerReset ends the subscriptions of a Learner which has been reset.
+var errLearnerReset = errors.New("learner has been reset")
+
+// Learner is a non-voting member which follows the commit logs of Acceptors.
+// It materializes the latest chosen version of every key and serves reads
+// that may be stale, together with a bound of the staleness.
+type Learner struct {
+	acceptorIds []int64
+	// Now is the clock expiration is decided by, time.Now if nil.
+	Now func() time.Time
+
+	mu sync.RWMutex
+	// view stores the chosen instances of every key from the latest one in
+	// effect, in increasing order of version, by storage key.
+	view map[string][]*Instance
+	// txns stores the status of every transaction learned, by its id prefixed
+	// with the namespace like a storage key.
+	txns map[string]int64
+	// positions stores the next commit log position to learn of every Acceptor.
+	positions map[int64]int64
+	// syncedAt stores the last time the Learner caught up with every Acceptor.
+	syncedAt map[int64]time.Time
+	// generation is increased every time the Learner is reset, the
+	// subscriptions started before end.
+	generation int64
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewLearner creates a Learner following the specified Acceptors.
+func NewLearner(acceptorIds []int64) *Learner {
+	return &Learner{
+		acceptorIds: acceptorIds,
+		view:        map[string][]*Instance{},
+		txns:        map[string]int64{},
+		positions:   map[int64]int64{},
+		syncedAt:    map[int64]time.Time{},
+	}
+}
+
+// Start subscribes to the commit log of every Acceptor in background.
+func (l *Learner) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+
+	for _, aid := range l.acceptorIds {
+		l.wg.Add(1)
+		go func(aid int64) {
+			defer l.wg.Done()
+			l.follow(ctx, aid)
+		}(aid)
+	}
+}
+
+// Stop stops following Acceptors and waits for background goroutines to exit.
+func (l *Learner) Stop() {
+	if l.cancel != nil {
+		l.cancel()
+	}
+	l.wg.Wait()
+}
+
+// Get returns the latest chosen instance of the key the Learner knows of.
+// The returned duration bounds the staleness of the instance: no instance
+// appended before that long ago to the commit log of any followed Acceptor is
+// missing. If the Learner has not caught up with every Acceptor yet, the
+// bound is -1.
+//
+// The bound covers the commit logs only: a value chosen, whose Commits are
+// lost on every followed Acceptor, is missing until paxos runs on its
+// instance again, e.g. by a Client reading the key.
+//
+// Intents of transactions take effect once the Learner learns the transaction
+// is committed, see Txn. A key whose latest version in effect is a tombstone
+// or has expired is reported as absent.
+func (l *Learner) Get(key string) (*Instance, time.Duration, bool) {
+	return l.GetIn("", key)
+}
+
+// GetIn returns the latest chosen instance of the key in the namespace, see Get.
+func (l *Learner) GetIn(namespace, key string) (*Instance, time.Duration, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	// The Acceptor caught up with the longest ago bounds the staleness.
+	staleness := time.Duration(-1)
+	if len(l.syncedAt) == len(l.acceptorIds) {
+		for _, t := range l.syncedAt {
+			if d := time.Since(t); d > staleness {
+				staleness = d
+			}
+		}
+	}
+
+	versions := l.view[storageKey(namespace, key)]
+	for i := len(versions) - 1; i >= 0; i-- {
+		inst := versions[i]
+		if !l.inEffect(inst) {
+			continue
+		}
+		if inst.Val.Tombstone || inst.Val.Expired(l.now()) {
+			return nil, staleness, false
+		}
+		return inst, staleness, true
+	}
+	return nil, staleness, false
+}
+
+// now returns the current time of the clock of the Learner.
+func (l *Learner) now() time.Time {
+	if l.Now != nil {
+		return l.Now()
+	}
+	return time.Now()
+}
+
+// inEffect reports whether the instance is not an intent of a transaction
+// which is pending or aborted as far as the Learner knows. l.mu must be held.
+func (l *Learner) inEffect(inst *Instance) bool {
+	return inst.Val.TxnId == "" || l.txns[storageKey(inst.Id.Namespace, inst.Val.TxnId)] == TxnCommitted
+}
+
+// follow keeps subscribing to the commit log of an Acceptor until ctx is done.
+func (l *Learner) follow(ctx context.Context, aid int64) {
+	for {
+		if err := l.subscribe(ctx, aid); err != nil && ctx.Err() == nil {
+			transportLog.Errorf("Learner: subscription to Acceptor-%d broken: %v", aid, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(LearnerRetryInterval):
+		}
+	}
+}
+
+// subscribe learns from the commit log of an Acceptor from the last learned position.
+//
+// If the position has been compacted, the instances missed may have deleted
+// keys, the Learner is reset to learn every commit log from the start again.
+func (l *Learner) subscribe(ctx context.Context, aid int64) error {
+	conn, err := dialAcceptor(aid)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	l.mu.RLock()
+	from, generation := l.positions[aid]+1, l.generation
+	l.mu.RUnlock()
+
+	stream, err := NewPaxosKVClient(conn).Subscribe(ctx, &SubscribeRequest{FromIndex: from})
+	if err != nil {
+		return err
+	}
+
+	for {
+		inst, err := stream.Recv()
+		if status.Code(err) == codes.OutOfRange {
+			l.reset(generation)
+		}
+		if err != nil {
+			return err
+		}
+		if !l.learn(aid, generation, inst) {
+			return errLearnerReset
+		}
+	}
+}
+
+// reset forgets everything learned, unless the Learner has been reset since
+// the generation.
+func (l *Learner) reset(generation int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.generation != generation {
+		return
+	}
+	transportLog.Warnf("Learner: commit log compacted, learn from the start again")
+	l.generation += 1
+	l.view = map[string][]*Instance{}
+	l.txns = map[string]int64{}
+	l.positions = map[int64]int64{}
+	l.syncedAt = map[int64]time.Time{}
+}
+
+// learn applies an instance received from an Acceptor to the view, it returns
+// false if the Learner has been reset since the generation.
+func (l *Learner) learn(aid int64, generation int64, inst *Instance) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.generation != generation {
+		return false
+	}
+	if inst.Id == nil {
+		l.syncedAt[aid] = time.Now()
+		return true
+	}
+
+	l.positions[aid] = inst.Index
+
+	// The status of a transaction is chosen on version 0 of its record, a
+	// later version deletes the record.
+	if id := strings.TrimPrefix(inst.Id.Key, TxnRecordPrefix); id != inst.Id.Key {
+		if inst.Id.Ver == 0 {
+			l.txns[storageKey(inst.Id.Namespace, id)] = inst.Val.Vi64
+		} else {
+			delete(l.txns, storageKey(inst.Id.Namespace, id))
+		}
+		return true
+	}
+
+	key := storageKey(inst.Id.Namespace, inst.Id.Key)
+	versions := l.view[key]
+	i := sort.Search(len(versions), func(i int) bool { return versions[i].Id.Ver >= inst.Id.Ver })
+	if i < len(versions) && versions[i].Id.Ver == inst.Id.Ver {
+		return true
+	}
+	versions = append(versions, nil)
+	copy(versions[i+1:], versions[i:])
+	versions[i] = inst
+
+	// The versions below the latest one in effect are no longer needed.
+	i = len(versions) - 1
+	for i > 0 && !l.inEffect(versions[i]) {
+		i--
+	}
+	l.view[key] = versions[i:]
+	return true
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestLearner_FollowChosenValues(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	learner := NewLearner(acceptorIds)
+	learner.Start()
+	defer learner.Stop()
+
+	// set k-0 = 5, k-1 = 6
+	for ver, val := range []int64{5, 6} {
+		prop := Proposer{
+			Id:  &PaxosInstanceId{Key: "k", Ver: int64(ver)},
+			Bal: &BallotNum{N: 0, ProposerId: 2},
+		}
+		prop.RunPaxos(acceptorIds, &Value{Vi64: val})
+	}
+
+	// the staleness is bounded once the Learner catches up with every Acceptor
+	r.Eventually(func() bool {
+		inst, staleness, ok := learner.Get("k")
+		return ok && inst.Id.Ver == 1 && staleness >= 0
+	}, 3*time.Second, 10*time.Millisecond)
+
+	inst, staleness, _ := learner.Get("k")
+	r.Equal(int64(6), inst.Val.Vi64)
+	r.GreaterOrEqual(staleness, time.Duration(0))
+	r.Less(staleness, time.Second)
+
+	_, _, ok := learner.Get("absent")
+	r.False(ok)
+
+	// k-2 expires by the clock of the Learner
+	now := time.Now()
+	learner.Now = func() time.Time { return now }
+	prop := Proposer{
+		Id:  &PaxosInstanceId{Key: "k", Ver: 2},
+		Bal: &BallotNum{N: 0, ProposerId: 2},
+	}
+	prop.RunPaxos(acceptorIds, &Value{Vi64: 7, ExpireAt: now.Add(time.Minute).UnixNano()})
+	r.Eventually(func() bool {
+		inst, _, ok := learner.Get("k")
+		return ok && inst.Id.Ver == 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	now = now.Add(time.Minute)
+	_, _, ok = learner.Get("k")
+	r.False(ok)
+}
+
+func TestLearner_ResetOnCompactedLog(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	client := NewClient(acceptorIds, 1)
+	_, err := client.Set("k", &Value{Vi64: 1})
+	r.Nil(err)
+	_, err = client.Set("d", &Value{Vi64: 1})
+	r.Nil(err)
+
+	learner := NewLearner(acceptorIds)
+	learner.Start()
+	r.Eventually(func() bool {
+		_, _, ok := learner.Get("d")
+		return ok
+	}, 3*time.Second, 10*time.Millisecond)
+	learner.Stop()
+
+	// the deletion is reclaimed while the Learner is away
+	_, err = client.Delete("d")
+	r.Nil(err)
+	_, err = client.Set("k", &Value{Vi64: 2})
+	r.Nil(err)
+	for _, kv := range servers.KVServers() {
+		kv.CollectGarbage()
+	}
+
+	learner.Start()
+	defer learner.Stop()
+	r.Eventually(func() bool {
+		inst, staleness, ok := learner.Get("k")
+		return ok && inst.Val.Vi64 == 2 && staleness >= 0
+	}, 3*time.Second, 10*time.Millisecond)
+	_, _, ok := learner.Get("d")
+	r.False(ok)
+}
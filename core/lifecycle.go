@@ -0,0 +1,328 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+var ErrShuttingDown = status.Error(codes.Unavailable, "acceptor is shutting down")
+
+// listen listens on the port of an Acceptor, replaced in tests.
+var listen = net.Listen
+
+// AcceptorServer serves an Acceptor over gRPC, and its metrics and health over
+// HTTP if enabled.
+type AcceptorServer struct {
+	Id int64
+
+	kv      *KVServer
+	grpc    *grpc.Server
+	metrics *http.Server
+	health  *probeServer
+
+	// done is closed once the gRPC server stops serving, err is the error it
+	// stops with.
+	done chan struct{}
+	err  error
+	// stopGC stops collecting garbage periodically.
+	stopGC func()
+	// stopAudit stops writing the audit records in the background.
+	stopAudit func()
+}
+
+// start serves the Acceptor on its port in the background.
+func (as *AcceptorServer) start() error {
+	addr := fmt.Sprintf(":%d", AcceptorBasePort+int(as.Id))
+	listener, err := listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	server, err := as.kv.newGRPCServer()
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+	health, err := as.kv.serveHealth()
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+	as.grpc, as.err = server, nil
+	as.health = health
+	as.metrics = as.kv.serveMetrics()
+	as.stopGC = as.kv.startGC()
+	as.stopAudit = as.kv.startAudit()
+
+	// The Acceptor is ready once the server starts accepting connections.
+	accepting := make(chan struct{})
+	l := &acceptingListener{Listener: listener, accepting: func() {
+		as.kv.markReady()
+		close(accepting)
+	}}
+
+	as.done = make(chan struct{})
+	go func() {
+		defer close(as.done)
+		// ErrServerStopped means the server is stopped before serving.
+		if err := as.grpc.Serve(l); err != nil && err != grpc.ErrServerStopped {
+			acceptorLog.Errorf("Acceptor-%d: failed to serve: %v", as.Id, err)
+			as.err = err
+			as.kv.drain()
+			as.release()
+		}
+	}()
+
+	select {
+	case <-accepting:
+	case <-as.done:
+		return as.err
+	}
+	acceptorLog.Infof("Acceptor-%d is serving on %s", as.Id, addr)
+	return nil
+}
+
+// Restart serves the stopped Acceptor again with the storage it had, as if
+// the storage were durable, e.g. to recover from a simulated crash.
+func (as *AcceptorServer) Restart() error {
+	as.kv.undrain()
+	return as.start()
+}
+
+// Stop stops the Acceptor immediately, the in-flight requests are canceled,
+// like a crash. It can be served again with Restart.
+func (as *AcceptorServer) Stop() {
+	as.kv.drain()
+	as.grpc.Stop()
+	<-as.done
+	as.release()
+}
+
+// release stops what serves along with the gRPC server: the health and
+// metrics servers, collecting garbage and writing the audit records. It is
+// called once the gRPC server stops, by Stop or as it fails, and may be called
+// more than once.
+func (as *AcceptorServer) release() {
+	as.stopGC()
+	if as.metrics != nil {
+		_ = as.metrics.Close()
+	}
+	if as.health != nil {
+		_ = as.health.Close()
+	}
+	as.stopAudit()
+}
+
+// Shutdown stops the Acceptor gracefully: it reports not ready, stops
+// accepting new requests, ends the streams, waits for the in-flight requests
+// to finish and flushes the storage. If ctx is done first, the Acceptor is
+// stopped immediately and the error of ctx is returned.
+func (as *AcceptorServer) Shutdown(ctx context.Context) error {
+	as.kv.drain()
+	as.stopGC()
+
+	stopped := make(chan struct{})
+	go func() {
+		as.grpc.GracefulStop()
+		close(stopped)
+	}()
+
+	var errs []error
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		acceptorLog.Warnf("Acceptor-%d: stopped before in-flight requests finished", as.Id)
+		as.grpc.Stop()
+		errs = append(errs, ctx.Err())
+	}
+
+	if err := as.kv.flush(); err != nil {
+		errs = append(errs, err)
+	}
+	if as.metrics != nil {
+		if err := as.metrics.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if as.health != nil {
+		if err := as.health.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	<-as.done
+	as.stopAudit()
+
+	acceptorLog.Infof("Acceptor-%d is shut down", as.Id)
+	return joinErrors(errs)
+}
+
+// Wait blocks until the Acceptor stops serving, and returns the error it
+// stops with, nil if it is stopped by Stop or Shutdown.
+func (as *AcceptorServer) Wait() error {
+	<-as.done
+	return as.err
+}
+
+// Acceptors are the acceptors started by ServeAcceptors.
+type Acceptors struct {
+	servers []*AcceptorServer
+}
+
+// Server returns the server of an acceptor, nil if it is not served.
+func (a *Acceptors) Server(aid int64) *AcceptorServer {
+	for _, as := range a.servers {
+		if as.Id == aid {
+			return as
+		}
+	}
+	return nil
+}
+
+// Stop stops all the acceptors immediately.
+func (a *Acceptors) Stop() {
+	for _, as := range a.servers {
+		as.Stop()
+	}
+}
+
+// Shutdown stops all the acceptors gracefully at the same time, see
+// AcceptorServer.Shutdown.
+func (a *Acceptors) Shutdown(ctx context.Context) error {
+	errs := make([]error, len(a.servers))
+
+	var wg sync.WaitGroup
+	for i, as := range a.servers {
+		wg.Add(1)
+		go func(i int, as *AcceptorServer) {
+			defer wg.Done()
+			errs[i] = as.Shutdown(ctx)
+		}(i, as)
+	}
+	wg.Wait()
+
+	return joinErrors(errs)
+}
+
+// Wait blocks until all the acceptors stop serving, and returns the errors
+// they stop with.
+func (a *Acceptors) Wait() error {
+	var errs []error
+	for _, as := range a.servers {
+		errs = append(errs, as.Wait())
+	}
+	return joinErrors(errs)
+}
+
+// drain reports the Acceptor not ready and ends the streams, so that the
+// subscribers and watchers switch to other Acceptors.
+func (s *KVServer) drain() {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+	s.setReady(false)
+	select {
+	case <-s.draining:
+	default:
+		if s.draining != nil {
+			close(s.draining)
+		}
+	}
+}
+
+// markReady reports the Acceptor ready unless it has started to shut down.
+func (s *KVServer) markReady() {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+	select {
+	case <-s.draining:
+	default:
+		s.setReady(true)
+	}
+}
+
+// acceptingListener calls accepting once the server starts accepting
+// connections on the listener.
+type acceptingListener struct {
+	net.Listener
+	once      sync.Once
+	accepting func()
+}
+
+func (l *acceptingListener) Accept() (net.Conn, error) {
+	l.once.Do(l.accepting)
+	return l.Listener.Accept()
+}
+
+// undrain lets a drained Acceptor serve streams again.
+func (s *KVServer) undrain() {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+	select {
+	case <-s.draining:
+		s.draining = make(chan struct{})
+	default:
+	}
+}
+
+// drained returns a channel closed when the Acceptor starts to shut down.
+func (s *KVServer) drained() <-chan struct{} {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+	return s.draining
+}
+
+// flush flushes the storage of the Acceptor.
+//
+// Storage is in memory, only the queued audit records are written and the
+// audit output is synced if it is a file.
+func (s *KVServer) flush() error {
+	if s.audit == nil || s.audit.out == nil {
+		return nil
+	}
+	return s.audit.flush()
+}
+
+// joinErrors returns nil if every error is nil, the only error if there is
+// one, or an error listing all of them, which unwraps to the first one.
+func joinErrors(errs []error) error {
+	var list errorList
+	for _, err := range errs {
+		if err != nil {
+			list = append(list, err)
+		}
+	}
+
+	switch len(list) {
+	case 0:
+		return nil
+	case 1:
+		return list[0]
+	}
+	return list
+}
+
+// errorList is the errors of stopping many Acceptors, or of the steps of
+// stopping one.
+type errorList []error
+
+func (l errorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (l errorList) Unwrap() error {
+	return l[0]
+}
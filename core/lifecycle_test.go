@@ -0,0 +1,188 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestAcceptors_Shutdown(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds, WithMetrics(9120))
+	defer servers.Stop()
+
+	conn, err := dialAcceptor(0)
+	r.Nil(err)
+	defer conn.Close()
+	stream, err := NewPaxosKVClient(conn).Subscribe(context.Background(), &SubscribeRequest{FromIndex: 1})
+	r.Nil(err)
+	_, err = stream.Recv()
+	r.Nil(err)
+
+	// the open stream is ended rather than waited for
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r.Nil(servers.Shutdown(ctx))
+	r.Nil(servers.Wait())
+
+	for {
+		if _, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	r.Equal(codes.Unavailable, status.Code(err))
+
+	_, err = http.Get("http://127.0.0.1:9120/readyz")
+	r.NotNil(err)
+	_, err = NewClient(acceptorIds, 1).Set("k", &Value{Vi64: 1})
+	r.NotNil(err)
+}
+
+// blockPrepare holds the storage lock of the Acceptor, so that a Prepare
+// request sent to it stays in flight until the returned function is called,
+// which waits for the request to be handled.
+func blockPrepare(t *testing.T, r *require.Assertions, as *AcceptorServer) (<-chan error, func()) {
+	as.kv.mu.Lock()
+
+	errc := make(chan error, 1)
+	go func() {
+		conn, err := dialAcceptor(as.Id)
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer conn.Close()
+		_, err = NewPaxosKVClient(conn).Prepare(context.Background(),
+			&Proposer{Id: &PaxosInstanceId{Key: "k", Ver: 0}, Bal: &BallotNum{N: 1, ProposerId: 1}})
+		errc <- err
+	}()
+
+	// Prepare holds the fence for reading once it is handled
+	r.Eventually(func() bool {
+		if as.kv.fenceMu.TryLock() {
+			as.kv.fenceMu.Unlock()
+			return false
+		}
+		return true
+	}, time.Second, time.Millisecond)
+
+	// the request is observed once its handler returns
+	var once sync.Once
+	unblock := func() {
+		once.Do(func() {
+			as.kv.mu.Unlock()
+			r.Eventually(func() bool {
+				return testutil.CollectAndCount(as.kv.metrics.requests) > 0
+			}, time.Second, time.Millisecond)
+		})
+	}
+	t.Cleanup(unblock)
+	return errc, unblock
+}
+
+func TestAcceptorServer_ShutdownInFlight(t *testing.T) {
+	r := require.New(t)
+
+	servers := ServeAcceptors([]int64{0})
+	defer servers.Stop()
+
+	as := servers.Server(0)
+	errc, unblock := blockPrepare(t, r, as)
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- as.Shutdown(ctx)
+	}()
+
+	// the Acceptor reports not ready at once, but waits for the request
+	r.Eventually(func() bool { return !as.kv.isReady() }, time.Second, time.Millisecond)
+	select {
+	case err := <-shutdown:
+		r.FailNow("shut down with a request in flight", "%v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unblock()
+	r.Nil(<-errc)
+	r.Nil(<-shutdown)
+	r.Nil(as.Wait())
+}
+
+func TestAcceptorServer_ShutdownTimeout(t *testing.T) {
+	r := require.New(t)
+
+	servers := ServeAcceptors([]int64{0})
+	defer servers.Stop()
+
+	as := servers.Server(0)
+	errc, unblock := blockPrepare(t, r, as)
+
+	// the in-flight request is canceled once ctx is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.ErrorIs(as.Shutdown(ctx), context.Canceled)
+	r.NotNil(<-errc)
+	unblock()
+	r.Nil(as.Wait())
+	r.Nil(servers.Server(1))
+}
+
+// failingListener fails to accept any connection.
+type failingListener struct {
+	net.Listener
+}
+
+func (l failingListener) Accept() (net.Conn, error) {
+	return nil, errors.New("accept failed")
+}
+
+func TestAcceptorServer_ServeFails(t *testing.T) {
+	r := require.New(t)
+
+	defer func() { listen = net.Listen }()
+	listen = func(network, address string) (net.Listener, error) {
+		l, err := net.Listen(network, address)
+		if err != nil {
+			return nil, err
+		}
+		t.Cleanup(func() { _ = l.Close() })
+		return failingListener{l}, nil
+	}
+
+	s := NewKVServer(WithHealth(9140), WithMetrics(9150), WithGC(time.Millisecond), WithAudit(&syncBuffer{}))
+	as := &AcceptorServer{Id: 0, kv: s}
+	err := as.start()
+	if err == nil {
+		err = as.Wait()
+	}
+	r.NotNil(err)
+
+	// everything started along with the gRPC server is stopped
+	r.False(s.isReady())
+	r.Eventually(func() bool {
+		_, err := http.Get("http://127.0.0.1:9150/metrics")
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+	_, err = http.Get("http://127.0.0.1:9140/healthz")
+	r.NotNil(err)
+	s.audit.mu.Lock()
+	r.Nil(s.audit.stop)
+	s.audit.mu.Unlock()
+	as.Stop()
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/khighness/highness-paxos-kv/pkg/logging"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// Loggers of the subsystems, whose levels can be changed at runtime.
+//
+// Messages of paxos instances are logged on the hot path, they are logged at
+// Debug level with the typed loggers and structured fields, see instanceFields.
+var (
+	acceptorLogger  = logging.Logger(logging.Acceptor)
+	proposerLogger  = logging.Logger(logging.Proposer)
+	transportLogger = logging.Logger(logging.Transport)
+
+	acceptorLog  = acceptorLogger.Sugar()
+	proposerLog  = proposerLogger.Sugar()
+	transportLog = transportLogger.Sugar()
+)
+
+// The outcomes of paxos messages.
+const (
+	outcomePromised  = "promised"
+	outcomeAccepted  = "accepted"
+	outcomeCommitted = "committed"
+	// outcomeLowerBallot means the ballot is lower than the one promised.
+	outcomeLowerBallot = "lower_ballot"
+	// outcomeKnown means the chosen value has been committed before.
+	outcomeKnown  = "known"
+	outcomeFailed = "failed"
+)
+
+// instanceFields returns the fields identifying a paxos instance and a ballot,
+// so that the logs can be filtered by instance.
+func instanceFields(id *PaxosInstanceId, bal *BallotNum, extra ...zap.Field) []zap.Field {
+	return append([]zap.Field{
+		zap.String("namespace", id.GetNamespace()),
+		zap.String("key", id.GetKey()),
+		zap.Int64("ver", id.GetVer()),
+		zap.Int64("ballot", bal.GetN()),
+		zap.Int64("proposer", bal.GetProposerId()),
+	}, extra...)
+}
+
+// logRequest logs a paxos request handled by the Acceptor at Debug level.
+func (s *KVServer) logRequest(method string, r *Proposer, outcome string, start time.Time, err error) {
+	if err != nil {
+		outcome = outcomeFailed
+	}
+	if ce := acceptorLogger.Check(zap.DebugLevel, "Acceptor: request handled"); ce != nil {
+		ce.Write(instanceFields(r.Id, r.Bal,
+			zap.String("method", method),
+			zap.Int64("acceptor", s.id),
+			zap.String("outcome", outcome),
+			zap.Duration("latency", time.Since(start)),
+			zap.Error(err))...)
+	}
+}
+
+// logKeyRequest logs a read request on keys handled by the Acceptor at Debug level.
+func (s *KVServer) logKeyRequest(method, namespace, key string, start time.Time, err error) {
+	if ce := acceptorLogger.Check(zap.DebugLevel, "Acceptor: request handled"); ce != nil {
+		ce.Write(
+			zap.String("method", method),
+			zap.String("namespace", namespace),
+			zap.String("key", key),
+			zap.Int64("acceptor", s.id),
+			zap.Duration("latency", time.Since(start)),
+			zap.Error(err))
+	}
+}
+
+// LogLevel handles LogLevel request. The levels are of the whole process,
+// shared by all the Acceptors in it.
+func (s *KVServer) LogLevel(c context.Context, r *LogLevelRequest) (*LogLevelReply, error) {
+	acceptorLog.Infof("Acceptor: receive LogLevel request: %v", r)
+
+	if r.Level != "" {
+		if err := logging.SetLevel(r.Subsystem, r.Level); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		acceptorLog.Warnf("Acceptor: log level of %s changed to %s", r.Subsystem, r.Level)
+	}
+	return &LogLevelReply{Levels: logging.Levels()}, nil
+}
+
+// SetLogLevel changes the log level of the subsystem of the process of an
+// Acceptor, or only returns the levels if level is empty.
+func SetLogLevel(aid int64, subsystem, level string) (map[string]string, error) {
+	conn, err := dialAcceptor(aid)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	reply, err := NewPaxosKVClient(conn).LogLevel(ctx, &LogLevelRequest{Subsystem: subsystem, Level: level})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Levels, nil
+}
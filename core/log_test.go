@@ -0,0 +1,57 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/khighness/highness-paxos-kv/pkg/logging"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestLog_PaxosFields(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	output := filepath.Join(t.TempDir(), "app.log")
+	closeLog, err := logging.Setup(logging.Config{
+		Format:      logging.FormatJSON,
+		OutputPaths: []string{output},
+		Levels:      map[string]string{logging.Acceptor: "debug"},
+	})
+	r.Nil(err)
+
+	p := Proposer{Id: &PaxosInstanceId{Key: "k", Ver: 3}, Bal: &BallotNum{N: 2, ProposerId: 1}}
+	_, err = p.Propose(context.Background(), acceptorIds, &Value{Vi64: 1})
+	r.Nil(err)
+	closeLog()
+	defer func() { _ = logging.SetLevel(logging.Acceptor, "info") }()
+
+	data, err := os.ReadFile(output)
+	r.Nil(err)
+
+	// messages of proposers are not logged at the default level
+	outcomes := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		entry := map[string]interface{}{}
+		r.Nil(json.Unmarshal([]byte(line), &entry))
+		r.Equal("acceptor", entry["logger"])
+		r.Equal("k", entry["key"])
+		r.Equal(float64(3), entry["ver"])
+		r.Equal(float64(2), entry["ballot"])
+		r.Equal(float64(1), entry["proposer"])
+		r.Contains(entry, "latency")
+		outcomes[entry["method"].(string)+"/"+entry["outcome"].(string)] += 1
+	}
+	r.Equal(map[string]int{"Prepare/promised": 3, "Accept/accepted": 3, "Commit/committed": 3}, outcomes)
+}
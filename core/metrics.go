@@ -0,0 +1,233 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// Metrics of Proposers, shared by all the Proposers in the process and
+// registered to the default registry.
+var (
+	proposerRounds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "paxoskv",
+		Subsystem: "proposer",
+		Name:      "rounds_per_commit",
+		Help:      "Number of phase-1 rounds a Proposer runs until a value is chosen.",
+		Buckets:   []float64{1, 2, 3, 5, 8, 13, 21},
+	})
+	proposerRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "paxoskv",
+		Subsystem: "proposer",
+		Name:      "retries_total",
+		Help:      "Number of rounds retried, by the phase failed to constitute a quorum.",
+	}, []string{"phase"})
+	proposerBallotBumps = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "paxoskv",
+		Subsystem: "proposer",
+		Name:      "ballot_bumps_total",
+		Help:      "Number of times a Proposer raised its ballot above a higher one seen.",
+	})
+	proposerProposals = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "paxoskv",
+		Subsystem: "proposer",
+		Name:      "proposals_total",
+		Help:      "Number of proposals, by result: chosen, empty or rejected.",
+	}, []string{"result"})
+)
+
+// auditRecordsDropped counts the audit records dropped from the output shared
+// by the acceptors in the process, registered to the default registry.
+var auditRecordsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "paxoskv",
+	Subsystem: "acceptor",
+	Name:      "audit_records_dropped_total",
+	Help:      "Number of audit records dropped as the audit output falls behind.",
+})
+
+func init() {
+	prometheus.MustRegister(proposerRounds, proposerRetries, proposerBallotBumps, proposerProposals, auditRecordsDropped)
+}
+
+// acceptorMetrics are the metrics of an Acceptor, registered to its own registry.
+//
+// Storage is in memory and nothing is fsynced, the storage latency covers
+// acquiring the storage lock and looking up or creating a version.
+type acceptorMetrics struct {
+	registry   *prometheus.Registry
+	requests   *prometheus.HistogramVec
+	rejections *prometheus.CounterVec
+	storage    prometheus.Histogram
+	keys       prometheus.Gauge
+	versions   prometheus.Gauge
+}
+
+func newAcceptorMetrics() *acceptorMetrics {
+	m := &acceptorMetrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "paxoskv",
+			Subsystem: "acceptor",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests handled, by method.",
+			Buckets:   prometheus.ExponentialBuckets(0.00005, 4, 10),
+		}, []string{"method"}),
+		rejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "paxoskv",
+			Subsystem: "acceptor",
+			Name:      "rejections_total",
+			Help:      "Number of requests rejected, by method and reason.",
+		}, []string{"method", "reason"}),
+		storage: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "paxoskv",
+			Subsystem: "acceptor",
+			Name:      "storage_duration_seconds",
+			Help:      "Latency of locking and looking up a version in storage.",
+			Buckets:   prometheus.ExponentialBuckets(0.000001, 4, 10),
+		}),
+		keys: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "paxoskv",
+			Subsystem: "acceptor",
+			Name:      "keys",
+			Help:      "Number of keys stored of all namespaces.",
+		}),
+		versions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "paxoskv",
+			Subsystem: "acceptor",
+			Name:      "versions",
+			Help:      "Number of versions stored of all namespaces.",
+		}),
+	}
+	m.registry.MustRegister(m.requests, m.rejections, m.storage, m.keys, m.versions)
+	return m
+}
+
+// The methods are no-ops on a nil receiver, for an Acceptor created without
+// NewKVServer.
+
+func (m *acceptorMetrics) reject(method, reason string) {
+	if m != nil {
+		m.rejections.WithLabelValues(method, reason).Inc()
+	}
+}
+
+func (m *acceptorMetrics) observeStorage(start time.Time) {
+	if m != nil {
+		m.storage.Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *acceptorMetrics) addKeys(delta int64) {
+	if m != nil {
+		m.keys.Add(float64(delta))
+	}
+}
+
+func (m *acceptorMetrics) addVersions(delta int64) {
+	if m != nil {
+		m.versions.Add(float64(delta))
+	}
+}
+
+// UnaryInterceptor records the latency and the rejection of unary requests.
+func (m *acceptorMetrics) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	reply, err := handler(ctx, req)
+	m.observe(info.FullMethod, start, err)
+	return reply, err
+}
+
+// StreamInterceptor records the rejection of streaming requests. The latency
+// of a stream is its lifetime, it is recorded as well.
+func (m *acceptorMetrics) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	m.observe(info.FullMethod, start, err)
+	return err
+}
+
+func (m *acceptorMetrics) observe(fullMethod string, start time.Time, err error) {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	m.requests.WithLabelValues(method).Observe(time.Since(start).Seconds())
+
+	switch status.Code(err) {
+	case codes.OK, codes.Canceled:
+	case codes.OutOfRange:
+		m.reject(method, "compacted")
+	case codes.FailedPrecondition:
+		m.reject(method, "not_voted")
+	case codes.Aborted:
+		m.reject(method, "stale_epoch")
+	case codes.InvalidArgument:
+		m.reject(method, "invalid_argument")
+	case codes.ResourceExhausted:
+		m.reject(method, "quota_exceeded")
+	case codes.Unauthenticated:
+		m.reject(method, "unauthenticated")
+	case codes.PermissionDenied:
+		m.reject(method, "permission_denied")
+	default:
+		m.reject(method, "error")
+	}
+}
+
+// WithMetrics serves the metrics of every acceptor over HTTP on /metrics of
+// port metricsBasePort + acceptor id, along with the metrics of the process.
+// The liveness and readiness of the acceptor are also served on /healthz and
+// /readyz, see WithHealth to serve them without metrics.
+func WithMetrics(metricsBasePort int) ServerOption {
+	return func(s *KVServer) {
+		s.metricsBasePort = metricsBasePort
+	}
+}
+
+// serveMetrics serves the metrics of the Acceptor if enabled.
+func (s *KVServer) serveMetrics() *http.Server {
+	if s.metricsBasePort == 0 || s.metrics == nil {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(
+		prometheus.Gatherers{s.metrics.registry, prometheus.DefaultGatherer},
+		promhttp.HandlerOpts{},
+	))
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+
+	server := &http.Server{Addr: fmt.Sprintf(":%d", s.metricsBasePort+int(s.id)), Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			acceptorLog.Errorf("Acceptor-%d: failed to serve metrics: %v", s.id, err)
+		}
+	}()
+	acceptorLog.Infof("Acceptor-%d is serving metrics on %s", s.id, server.Addr)
+	return server
+}
+
+// ServeMetrics serves the metrics of the process, e.g. of Proposers, over HTTP
+// on /metrics of the address.
+func ServeMetrics(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			zap.S().Errorf("failed to serve metrics on %s: %v", addr, err)
+		}
+	}()
+	return server
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestMetrics(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds, WithMetrics(9100), WithQuota("small", Quota{MaxKeys: 1}))
+	defer servers.Stop()
+
+	client := NewClient(acceptorIds, 1)
+	_, err := client.Set("k", &Value{Vi64: 1})
+	r.Nil(err)
+	small := &Client{AcceptorIds: acceptorIds, ProposerId: 1, Namespace: "small"}
+	_, err = small.Set("a", &Value{Vi64: 1})
+	r.Nil(err)
+	_, err = small.Set("b", &Value{Vi64: 1})
+	r.Equal(ErrQuotaExceeded, err)
+
+	resp, err := http.Get("http://127.0.0.1:9101/metrics")
+	r.Nil(err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	r.Nil(err)
+
+	r.Contains(string(body), `paxoskv_acceptor_request_duration_seconds_count{method="Accept"}`)
+	r.Contains(string(body), `paxoskv_acceptor_rejections_total{method="Accept",reason="quota_exceeded"} 1`)
+	r.Contains(string(body), "paxoskv_acceptor_keys 2")
+	r.Contains(string(body), "paxoskv_proposer_rounds_per_commit_count")
+	r.Contains(string(body), `paxoskv_proposer_proposals_total{result="rejected"}`)
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+var (
+	ErrQuotaExceeded = status.Error(codes.ResourceExhausted, "namespace quota exceeded")
+	// ErrInvalidKey rejects a key or a namespace containing the namespace separator.
+	ErrInvalidKey = status.Error(codes.InvalidArgument, "key or namespace contains the namespace separator")
+)
+
+// namespaceSep separates the namespace and the key in a storage key.
+const namespaceSep = "\x00"
+
+// Quota limits the usage of a namespace on an Acceptor, zero means no limit.
+type Quota struct {
+	// MaxKeys is the maximum number of keys.
+	MaxKeys int64
+	// MaxBytes is the maximum bytes of keys and voted values.
+	MaxBytes int64
+}
+
+// usage is the usage of a namespace on an Acceptor.
+type usage struct {
+	keys     int64
+	versions int64
+	bytes    int64
+	// charged stores the keys accounted, i.e. with a version voted.
+	charged map[string]bool
+}
+
+// storageKey returns the key of a record in Storage.
+// Keys of the default namespace are stored as is.
+func storageKey(namespace, key string) string {
+	if namespace == "" {
+		return key
+	}
+	return namespace + namespaceSep + key
+}
+
+// validateKey returns ErrInvalidKey if the namespace or the key contains the
+// namespace separator, with which a key of one namespace would collide with a
+// key of another, e.g. "a\x00b" of the default namespace with "b" of "a".
+func validateKey(namespace, key string) error {
+	if strings.Contains(namespace, namespaceSep) || strings.Contains(key, namespaceSep) {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// splitStorageKey returns the namespace and the key of a storage key.
+func splitStorageKey(skey string) (string, string) {
+	if i := strings.Index(skey, namespaceSep); i >= 0 {
+		return skey[:i], skey[i+1:]
+	}
+	return "", skey
+}
+
+// SetQuota sets the quota of a namespace. The quota is enforced on the keys
+// and values written from now on, the existing ones are kept.
+func (s *KVServer) SetQuota(namespace string, q Quota) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	if s.quotas == nil {
+		s.quotas = map[string]Quota{}
+	}
+	s.quotas[namespace] = q
+}
+
+// NamespaceStats handles NamespaceStats request.
+func (s *KVServer) NamespaceStats(c context.Context, r *NamespaceStatsRequest) (*NamespaceStatsReply, error) {
+	acceptorLog.Infof("Acceptor: receive NamespaceStats request: %v", r)
+
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	u := s.usageLocked(r.Namespace)
+	q := s.quotas[r.Namespace]
+	return &NamespaceStatsReply{
+		Keys:     u.keys,
+		Versions: u.versions,
+		Bytes:    u.bytes,
+		MaxKeys:  q.MaxKeys,
+		MaxBytes: q.MaxBytes,
+	}, nil
+}
+
+// useVote accounts a value voted on the key of the namespace, which changes
+// the bytes of voted values by delta. The key is accounted on its first vote,
+// so that a key only prepared is never charged. If enforceQuota is set,
+// growth beyond the quota fails.
+func (s *KVServer) useVote(namespace, key string, delta int64, enforceQuota bool) error {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	u, q := s.usageLocked(namespace), s.quotas[namespace]
+	var keys int64
+	if !u.charged[key] {
+		keys, delta = 1, delta+int64(len(key))
+	}
+	if enforceQuota && (q.MaxKeys > 0 && keys > 0 && u.keys+keys > q.MaxKeys ||
+		q.MaxBytes > 0 && delta > 0 && u.bytes+delta > q.MaxBytes) {
+		return ErrQuotaExceeded
+	}
+
+	if keys > 0 {
+		u.charged[key] = true
+		u.keys += keys
+		s.metrics.addKeys(keys)
+	}
+	u.bytes += delta
+	return nil
+}
+
+// useBytes accounts the change of voted values of the namespace.
+func (s *KVServer) useBytes(namespace string, delta int64) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	s.usageLocked(namespace).bytes += delta
+}
+
+// useVersions accounts the change of the number of versions of the namespace.
+func (s *KVServer) useVersions(namespace string, delta int64) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	s.usageLocked(namespace).versions += delta
+	s.metrics.addVersions(delta)
+}
+
+// releaseKey accounts the reclamation of a key of the namespace.
+func (s *KVServer) releaseKey(namespace, key string) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	u := s.usageLocked(namespace)
+	if !u.charged[key] {
+		return
+	}
+	delete(u.charged, key)
+	u.keys -= 1
+	u.bytes -= int64(len(key))
+	s.metrics.addKeys(-1)
+}
+
+func (s *KVServer) usageLocked(namespace string) *usage {
+	if s.usages == nil {
+		s.usages = map[string]*usage{}
+	}
+	u, ok := s.usages[namespace]
+	if !ok {
+		u = &usage{charged: map[string]bool{}}
+		s.usages[namespace] = u
+	}
+	return u
+}
+
+// NamespaceStatsOf returns the usage and quota of a namespace on an Acceptor.
+func NamespaceStatsOf(aid int64, namespace string) (*NamespaceStatsReply, error) {
+	conn, err := dialAcceptor(aid)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	return NewPaxosKVClient(conn).NamespaceStats(ctx, &NamespaceStatsRequest{Namespace: namespace})
+}
+
+// versionBytes returns the bytes a version accounts for, v.mu must be held.
+func versionBytes(v *Version) int64 {
+	return int64(proto.Size(v.acceptor.Val))
+}
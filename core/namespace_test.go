@@ -0,0 +1,165 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestClient_Namespace(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds, WithQuota("small", Quota{MaxKeys: 2}))
+	defer servers.Stop()
+
+	red := &Client{AcceptorIds: acceptorIds, ProposerId: 1, Namespace: "red"}
+	blue := &Client{AcceptorIds: acceptorIds, ProposerId: 2, Namespace: "blue"}
+
+	// the same key in different namespaces are different records
+	_, err := red.Set("k", &Value{Vi64: 1})
+	r.Nil(err)
+	_, err = red.Set("k", &Value{Vi64: 2})
+	r.Nil(err)
+	_, _, err = blue.Get("k")
+	r.Equal(ErrNotFound, err)
+
+	ver, err := blue.Set("k", &Value{Vi64: 3})
+	r.Nil(err)
+	r.Equal(int64(0), ver)
+
+	val, ver, err := red.Get("k")
+	r.Nil(err)
+	r.Equal(int64(1), ver)
+	r.Equal(int64(2), val.Vi64)
+
+	reply, err := NewClient(acceptorIds, 1).Scan(0, "", "", 0, "")
+	r.Nil(err)
+	r.Empty(reply.Instances)
+	reply, err = ScanAcceptor(0, &ScanRequest{Namespace: "blue"})
+	r.Nil(err)
+	r.Len(reply.Instances, 1)
+	r.Equal("blue", reply.Instances[0].Id.Namespace)
+	r.Equal(int64(3), reply.Instances[0].Val.Vi64)
+
+	stats, err := NamespaceStatsOf(0, "red")
+	r.Nil(err)
+	r.Equal(int64(1), stats.Keys)
+	r.Equal(int64(2), stats.Versions)
+	r.Positive(stats.Bytes)
+
+	// writes beyond the quota are rejected without retrying
+	small := &Client{AcceptorIds: acceptorIds, ProposerId: 1, Namespace: "small"}
+	_, err = small.Set("a", &Value{Vi64: 1})
+	r.Nil(err)
+	_, err = small.Set("b", &Value{Vi64: 1})
+	r.Nil(err)
+	_, err = small.Set("c", &Value{Vi64: 1})
+	r.Equal(ErrQuotaExceeded, err)
+
+	stats, err = NamespaceStatsOf(0, "small")
+	r.Nil(err)
+	r.Equal(int64(2), stats.Keys)
+	r.Equal(int64(2), stats.MaxKeys)
+
+	// existing keys can still be written and deleted
+	_, err = small.Set("a", &Value{Vi64: 2})
+	r.Nil(err)
+	_, err = small.Delete("b")
+	r.Nil(err)
+}
+
+func TestAcceptor_ByteQuota(t *testing.T) {
+	r := require.New(t)
+
+	s := NewKVServer(WithQuota("ns", Quota{MaxBytes: 16}))
+	accept := func(key string, val *Value) error {
+		_, err := s.Accept(nil, &Proposer{
+			Id:  &PaxosInstanceId{Key: key, Ver: 0, Namespace: "ns"},
+			Bal: &BallotNum{N: 1},
+			Val: val,
+		})
+		return err
+	}
+
+	r.Nil(accept("k", &Value{Data: []byte("0123456789")}))
+	r.Equal(ErrQuotaExceeded, accept("j", &Value{Data: []byte("0123456789")}))
+	r.Nil(accept("j", &Value{Tombstone: true}))
+
+	// the default namespace is not limited
+	_, err := s.Accept(nil, &Proposer{
+		Id:  &PaxosInstanceId{Key: "k", Ver: 0},
+		Bal: &BallotNum{N: 1},
+		Val: &Value{Data: make([]byte, 64)},
+	})
+	r.Nil(err)
+	r.Contains(s.Storage, "k")
+	r.Contains(s.Storage, "ns\x00k")
+}
+
+func TestAcceptor_NamespaceSeparator(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	// "red\x00k" of the default namespace would collide with "k" of red
+	red := &Client{AcceptorIds: acceptorIds, ProposerId: 1, Namespace: "red"}
+	_, err := red.Set("k", &Value{Vi64: 1})
+	r.Nil(err)
+
+	_, err = NewClient(acceptorIds, 2).Set("red\x00k", &Value{Vi64: 2})
+	r.Equal(ErrInvalidKey, err)
+	_, _, err = NewClient(acceptorIds, 2).Get("red\x00k")
+	r.Equal(ErrInvalidKey, err)
+	_, err = (&Client{AcceptorIds: acceptorIds, ProposerId: 2, Namespace: "r\x00ed"}).Set("k", &Value{Vi64: 2})
+	r.Equal(ErrInvalidKey, err)
+	_, err = ScanAcceptor(0, &ScanRequest{Namespace: "red\x00"})
+	r.Equal(codes.InvalidArgument, status.Code(err))
+
+	val, ver, err := red.Get("k")
+	r.Nil(err)
+	r.Equal(int64(0), ver)
+	r.Equal(int64(1), val.Vi64)
+}
+
+func TestAcceptor_PreparedKeyNotCharged(t *testing.T) {
+	r := require.New(t)
+
+	s := NewKVServer(WithQuota("ns", Quota{MaxKeys: 1}))
+	prepare := func(key string) error {
+		_, err := s.Prepare(nil, &Proposer{
+			Id:  &PaxosInstanceId{Key: key, Ver: 0, Namespace: "ns"},
+			Bal: &BallotNum{N: 1},
+		})
+		return err
+	}
+	accept := func(key string) error {
+		_, err := s.Accept(nil, &Proposer{
+			Id:  &PaxosInstanceId{Key: key, Ver: 0, Namespace: "ns"},
+			Bal: &BallotNum{N: 1},
+			Val: &Value{Vi64: 1},
+		})
+		return err
+	}
+
+	// keys only prepared, e.g. by reads of absent keys, take no quota
+	r.Nil(prepare("a"))
+	r.Nil(prepare("b"))
+	stats, err := s.NamespaceStats(nil, &NamespaceStatsRequest{Namespace: "ns"})
+	r.Nil(err)
+	r.Equal(int64(0), stats.Keys)
+
+	r.Nil(accept("b"))
+	r.Nil(accept("b"))
+	r.Equal(ErrQuotaExceeded, accept("a"))
+	stats, err = s.NamespaceStats(nil, &NamespaceStatsRequest{Namespace: "ns"})
+	r.Nil(err)
+	r.Equal(int64(1), stats.Keys)
+}
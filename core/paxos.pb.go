@@ -88,6 +88,16 @@ type Value struct {
 	unknownFields protoimpl.UnknownFields
 
 	Vi64 int64 `protobuf:"varint,1,opt,name=Vi64,proto3" json:"Vi64,omitempty"`
+	// whether the value is a tombstone, which marks the record as deleted.
+	Tombstone bool `protobuf:"varint,2,opt,name=Tombstone,proto3" json:"Tombstone,omitempty"`
+	// the unix time in nanoseconds the value expires at, 0 means never.
+	// It is stamped by the clock of the writer, Acceptors never read it.
+	ExpireAt int64 `protobuf:"varint,3,opt,name=ExpireAt,proto3" json:"ExpireAt,omitempty"`
+	// the transaction the value is written by as an intent, which takes effect
+	// only if the transaction commits.
+	TxnId string `protobuf:"bytes,4,opt,name=TxnId,proto3" json:"TxnId,omitempty"`
+	// the opaque payload of the value.
+	Data []byte `protobuf:"bytes,5,opt,name=Data,proto3" json:"Data,omitempty"`
 }
 
 func (x *Value) Reset() {
@@ -129,6 +139,34 @@ func (x *Value) GetVi64() int64 {
 	return 0
 }
 
+func (x *Value) GetTombstone() bool {
+	if x != nil {
+		return x.Tombstone
+	}
+	return false
+}
+
+func (x *Value) GetExpireAt() int64 {
+	if x != nil {
+		return x.ExpireAt
+	}
+	return 0
+}
+
+func (x *Value) GetTxnId() string {
+	if x != nil {
+		return x.TxnId
+	}
+	return ""
+}
+
+func (x *Value) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
 // PaxosInstanceId specifies which paxos instance it runs on.
 // A paxos instance is used to determine a specific version of a record.
 type PaxosInstanceId struct {
@@ -140,6 +178,8 @@ type PaxosInstanceId struct {
 	Key string `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
 	// the version of the record to modify.
 	Ver int64 `protobuf:"varint,2,opt,name=Ver,proto3" json:"Ver,omitempty"`
+	// the namespace of the record, records in different namespaces are isolated.
+	Namespace string `protobuf:"bytes,3,opt,name=Namespace,proto3" json:"Namespace,omitempty"`
 }
 
 func (x *PaxosInstanceId) Reset() {
@@ -188,6 +228,13 @@ func (x *PaxosInstanceId) GetVer() int64 {
 	return 0
 }
 
+func (x *PaxosInstanceId) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
 // Acceptor is the state of an Acceptor and also serves as the reply
 // of Prepare/Accept.
 type Acceptor struct {
@@ -269,6 +316,8 @@ type Proposer struct {
 	Bal *BallotNum `protobuf:"bytes,2,opt,name=Bal,proto3" json:"Bal,omitempty"`
 	// the value of a Proposer has chosen.
 	Val *Value `protobuf:"bytes,3,opt,name=Val,proto3" json:"Val,omitempty"`
+	// the epoch of the shard map the Proposer routes by, see Fence.
+	Epoch int64 `protobuf:"varint,4,opt,name=Epoch,proto3" json:"Epoch,omitempty"`
 }
 
 func (x *Proposer) Reset() {
@@ -324,239 +373,2062 @@ func (x *Proposer) GetVal() *Value {
 	return nil
 }
 
-var File_api_paxos_proto protoreflect.FileDescriptor
-
-var file_api_paxos_proto_rawDesc = []byte{
-	0x0a, 0x0f, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x12, 0x04, 0x63, 0x6f, 0x72, 0x65, 0x22, 0x39, 0x0a, 0x09, 0x42, 0x61, 0x6c, 0x6c, 0x6f,
-	0x74, 0x4e, 0x75, 0x6d, 0x12, 0x0c, 0x0a, 0x01, 0x4e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x01, 0x4e, 0x12, 0x1e, 0x0a, 0x0a, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x49, 0x64,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72,
-	0x49, 0x64, 0x22, 0x1b, 0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x56,
-	0x69, 0x36, 0x34, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x56, 0x69, 0x36, 0x34, 0x22,
-	0x35, 0x0a, 0x0f, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
-	0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x03, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x56, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x03, 0x52, 0x03, 0x56, 0x65, 0x72, 0x22, 0x79, 0x0a, 0x08, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74,
-	0x6f, 0x72, 0x12, 0x29, 0x0a, 0x07, 0x6c, 0x61, 0x73, 0x74, 0x42, 0x61, 0x6c, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x61, 0x6c, 0x6c, 0x6f,
-	0x74, 0x4e, 0x75, 0x6d, 0x52, 0x07, 0x6c, 0x61, 0x73, 0x74, 0x42, 0x61, 0x6c, 0x12, 0x1d, 0x0a,
-	0x03, 0x76, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x63, 0x6f, 0x72,
-	0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x76, 0x61, 0x6c, 0x12, 0x23, 0x0a, 0x04,
-	0x56, 0x42, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x63, 0x6f, 0x72,
-	0x65, 0x2e, 0x42, 0x61, 0x6c, 0x6c, 0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x52, 0x04, 0x56, 0x42, 0x61,
-	0x6c, 0x22, 0x73, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x12, 0x25, 0x0a,
-	0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x63, 0x6f, 0x72, 0x65,
-	0x2e, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64,
-	0x52, 0x02, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x03, 0x42, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x0f, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x61, 0x6c, 0x6c, 0x6f, 0x74, 0x4e,
-	0x75, 0x6d, 0x52, 0x03, 0x42, 0x61, 0x6c, 0x12, 0x1d, 0x0a, 0x03, 0x56, 0x61, 0x6c, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75,
-	0x65, 0x52, 0x03, 0x56, 0x61, 0x6c, 0x32, 0x62, 0x0a, 0x07, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x4b,
-	0x56, 0x12, 0x2b, 0x0a, 0x07, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x12, 0x0e, 0x2e, 0x63,
-	0x6f, 0x72, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x1a, 0x0e, 0x2e, 0x63,
-	0x6f, 0x72, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x2a,
-	0x0a, 0x06, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x12, 0x0e, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
-	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x1a, 0x0e, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
-	0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x22, 0x00, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x2f,
-	0x63, 0x6f, 0x72, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+func (x *Proposer) GetEpoch() int64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
 }
 
-var (
-	file_api_paxos_proto_rawDescOnce sync.Once
-	file_api_paxos_proto_rawDescData = file_api_paxos_proto_rawDesc
-)
+// Instance is a committed paxos instance, i.e. a chosen version of a record.
+type Instance struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func file_api_paxos_proto_rawDescGZIP() []byte {
-	file_api_paxos_proto_rawDescOnce.Do(func() {
-		file_api_paxos_proto_rawDescData = protoimpl.X.CompressGZIP(file_api_paxos_proto_rawDescData)
-	})
-	return file_api_paxos_proto_rawDescData
+	// which paxos instance has been committed.
+	Id *PaxosInstanceId `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	// the chosen value of the instance.
+	Val *Value `protobuf:"bytes,2,opt,name=Val,proto3" json:"Val,omitempty"`
+	// the position of the instance in the commit log of an Acceptor.
+	Index int64 `protobuf:"varint,3,opt,name=Index,proto3" json:"Index,omitempty"`
 }
 
-var file_api_paxos_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
-var file_api_paxos_proto_goTypes = []interface{}{
-	(*BallotNum)(nil),       // 0: core.BallotNum
-	(*Value)(nil),           // 1: core.Value
-	(*PaxosInstanceId)(nil), // 2: core.PaxosInstanceId
-	(*Acceptor)(nil),        // 3: core.Acceptor
-	(*Proposer)(nil),        // 4: core.Proposer
+func (x *Instance) Reset() {
+	*x = Instance{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-var file_api_paxos_proto_depIdxs = []int32{
-	0, // 0: core.Acceptor.lastBal:type_name -> core.BallotNum
-	1, // 1: core.Acceptor.val:type_name -> core.Value
-	0, // 2: core.Acceptor.VBal:type_name -> core.BallotNum
-	2, // 3: core.Proposer.Id:type_name -> core.PaxosInstanceId
-	0, // 4: core.Proposer.Bal:type_name -> core.BallotNum
-	1, // 5: core.Proposer.Val:type_name -> core.Value
-	4, // 6: core.PaxosKV.Prepare:input_type -> core.Proposer
-	4, // 7: core.PaxosKV.Accept:input_type -> core.Proposer
-	3, // 8: core.PaxosKV.Prepare:output_type -> core.Acceptor
-	3, // 9: core.PaxosKV.Accept:output_type -> core.Acceptor
-	8, // [8:10] is the sub-list for method output_type
-	6, // [6:8] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+
+func (x *Instance) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func init() { file_api_paxos_proto_init() }
-func file_api_paxos_proto_init() {
-	if File_api_paxos_proto != nil {
-		return
-	}
-	if !protoimpl.UnsafeEnabled {
-		file_api_paxos_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BallotNum); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_api_paxos_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Value); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_api_paxos_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PaxosInstanceId); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_api_paxos_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Acceptor); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_api_paxos_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Proposer); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
+func (*Instance) ProtoMessage() {}
+
+func (x *Instance) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
+		return ms
 	}
-	type x struct{}
-	out := protoimpl.TypeBuilder{
-		File: protoimpl.DescBuilder{
-			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_api_paxos_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   5,
-			NumExtensions: 0,
-			NumServices:   1,
-		},
-		GoTypes:           file_api_paxos_proto_goTypes,
-		DependencyIndexes: file_api_paxos_proto_depIdxs,
-		MessageInfos:      file_api_paxos_proto_msgTypes,
-	}.Build()
-	File_api_paxos_proto = out.File
-	file_api_paxos_proto_rawDesc = nil
-	file_api_paxos_proto_goTypes = nil
-	file_api_paxos_proto_depIdxs = nil
+	return mi.MessageOf(x)
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConnInterface
+// Deprecated: Use Instance.ProtoReflect.Descriptor instead.
+func (*Instance) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{5}
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion6
+func (x *Instance) GetId() *PaxosInstanceId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
 
-// PaxosKVClient is the client API for PaxosKV service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type PaxosKVClient interface {
-	Prepare(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error)
-	Accept(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error)
+func (x *Instance) GetVal() *Value {
+	if x != nil {
+		return x.Val
+	}
+	return nil
 }
 
-type paxosKVClient struct {
-	cc grpc.ClientConnInterface
+func (x *Instance) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
 }
 
-func NewPaxosKVClient(cc grpc.ClientConnInterface) PaxosKVClient {
-	return &paxosKVClient{cc}
+// SubscribeRequest asks an Acceptor to stream its commit log.
+//
+// An Acceptor sends an Instance without Id as a heartbeat whenever the
+// subscriber has caught up with the end of its commit log.
+type SubscribeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the first commit log position to stream, positions start from 1.
+	FromIndex int64 `protobuf:"varint,1,opt,name=FromIndex,proto3" json:"FromIndex,omitempty"`
 }
 
-func (c *paxosKVClient) Prepare(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error) {
-	out := new(Acceptor)
-	err := c.cc.Invoke(ctx, "/core.PaxosKV/Prepare", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return out, nil
 }
 
-func (c *paxosKVClient) Accept(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error) {
-	out := new(Acceptor)
-	err := c.cc.Invoke(ctx, "/core.PaxosKV/Accept", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return out, nil
+	return mi.MessageOf(x)
 }
 
-// PaxosKVServer is the server API for PaxosKV service.
-type PaxosKVServer interface {
-	Prepare(context.Context, *Proposer) (*Acceptor, error)
-	Accept(context.Context, *Proposer) (*Acceptor, error)
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{6}
 }
 
-// UnimplementedPaxosKVServer can be embedded to have forward compatible implementations.
-type UnimplementedPaxosKVServer struct {
+func (x *SubscribeRequest) GetFromIndex() int64 {
+	if x != nil {
+		return x.FromIndex
+	}
+	return 0
 }
 
-func (*UnimplementedPaxosKVServer) Prepare(context.Context, *Proposer) (*Acceptor, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Prepare not implemented")
+// WatchRequest asks an Acceptor to stream chosen versions of a key.
+type WatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the key to watch, or the key prefix if `Prefix` is set.
+	Key string `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	// whether to watch all the keys with `Key` as prefix.
+	Prefix bool `protobuf:"varint,2,opt,name=Prefix,proto3" json:"Prefix,omitempty"`
+	// the first version to stream.
+	FromVer int64 `protobuf:"varint,3,opt,name=FromVer,proto3" json:"FromVer,omitempty"`
+	// the namespace of the key.
+	Namespace string `protobuf:"bytes,4,opt,name=Namespace,proto3" json:"Namespace,omitempty"`
 }
-func (*UnimplementedPaxosKVServer) Accept(context.Context, *Proposer) (*Acceptor, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Accept not implemented")
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-func RegisterPaxosKVServer(s *grpc.Server, srv PaxosKVServer) {
-	s.RegisterService(&_PaxosKV_serviceDesc, srv)
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func _PaxosKV_Prepare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Proposer)
-	if err := dec(in); err != nil {
-		return nil, err
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	if interceptor == nil {
-		return srv.(PaxosKVServer).Prepare(ctx, in)
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WatchRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetPrefix() bool {
+	if x != nil {
+		return x.Prefix
+	}
+	return false
+}
+
+func (x *WatchRequest) GetFromVer() int64 {
+	if x != nil {
+		return x.FromVer
+	}
+	return 0
+}
+
+func (x *WatchRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+// LatestVersionRequest asks an Acceptor for the versions it has of a key.
+type LatestVersionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key string `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	// the namespace of the key.
+	Namespace string `protobuf:"bytes,2,opt,name=Namespace,proto3" json:"Namespace,omitempty"`
+}
+
+func (x *LatestVersionRequest) Reset() {
+	*x = LatestVersionRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LatestVersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatestVersionRequest) ProtoMessage() {}
+
+func (x *LatestVersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatestVersionRequest.ProtoReflect.Descriptor instead.
+func (*LatestVersionRequest) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LatestVersionRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *LatestVersionRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+// LatestVersionReply is the versions an Acceptor has of a key.
+type LatestVersionReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the highest version the Acceptor has voted a value for, -1 if none.
+	VotedVer int64 `protobuf:"varint,1,opt,name=VotedVer,proto3" json:"VotedVer,omitempty"`
+	// the highest version the Acceptor knows to be committed, -1 if none.
+	CommittedVer int64 `protobuf:"varint,2,opt,name=CommittedVer,proto3" json:"CommittedVer,omitempty"`
+	// the versions below Floor have been reclaimed by the Acceptor.
+	Floor int64 `protobuf:"varint,3,opt,name=Floor,proto3" json:"Floor,omitempty"`
+}
+
+func (x *LatestVersionReply) Reset() {
+	*x = LatestVersionReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LatestVersionReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatestVersionReply) ProtoMessage() {}
+
+func (x *LatestVersionReply) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatestVersionReply.ProtoReflect.Descriptor instead.
+func (*LatestVersionReply) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *LatestVersionReply) GetVotedVer() int64 {
+	if x != nil {
+		return x.VotedVer
+	}
+	return 0
+}
+
+func (x *LatestVersionReply) GetCommittedVer() int64 {
+	if x != nil {
+		return x.CommittedVer
+	}
+	return 0
+}
+
+func (x *LatestVersionReply) GetFloor() int64 {
+	if x != nil {
+		return x.Floor
+	}
+	return 0
+}
+
+// ScanRequest asks an Acceptor for the latest committed version of every key
+// in the range [StartKey, EndKey), in key order.
+type ScanRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the first key of the range, inclusive.
+	StartKey string `protobuf:"bytes,1,opt,name=StartKey,proto3" json:"StartKey,omitempty"`
+	// the end of the range, exclusive. An empty EndKey means no upper bound.
+	EndKey string `protobuf:"bytes,2,opt,name=EndKey,proto3" json:"EndKey,omitempty"`
+	// the maximum number of keys to return, no limit if not positive.
+	Limit int64 `protobuf:"varint,3,opt,name=Limit,proto3" json:"Limit,omitempty"`
+	// the NextPageToken of the previous page, it overrides StartKey and ReadPoint.
+	PageToken string `protobuf:"bytes,4,opt,name=PageToken,proto3" json:"PageToken,omitempty"`
+	// the commit log position to read at, 0 means the end of the commit log.
+	ReadPoint int64 `protobuf:"varint,5,opt,name=ReadPoint,proto3" json:"ReadPoint,omitempty"`
+	// whether to return keys whose latest version is a tombstone.
+	Tombstones bool `protobuf:"varint,6,opt,name=Tombstones,proto3" json:"Tombstones,omitempty"`
+	// the namespace to scan.
+	Namespace string `protobuf:"bytes,7,opt,name=Namespace,proto3" json:"Namespace,omitempty"`
+	// whether to return keys with versions voted but none committed at
+	// ReadPoint, with the latest voted version and Index 0.
+	Voted bool `protobuf:"varint,8,opt,name=Voted,proto3" json:"Voted,omitempty"`
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ScanRequest) GetStartKey() string {
+	if x != nil {
+		return x.StartKey
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetEndKey() string {
+	if x != nil {
+		return x.EndKey
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ScanRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetReadPoint() int64 {
+	if x != nil {
+		return x.ReadPoint
+	}
+	return 0
+}
+
+func (x *ScanRequest) GetTombstones() bool {
+	if x != nil {
+		return x.Tombstones
+	}
+	return false
+}
+
+func (x *ScanRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetVoted() bool {
+	if x != nil {
+		return x.Voted
+	}
+	return false
+}
+
+// ScanReply is a page of the result of a Scan.
+type ScanReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the latest committed version of every key at ReadPoint.
+	Instances []*Instance `protobuf:"bytes,1,rep,name=Instances,proto3" json:"Instances,omitempty"`
+	// the token to request the next page with, empty if it is the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=NextPageToken,proto3" json:"NextPageToken,omitempty"`
+	// the commit log position the page is read at.
+	ReadPoint int64 `protobuf:"varint,3,opt,name=ReadPoint,proto3" json:"ReadPoint,omitempty"`
+}
+
+func (x *ScanReply) Reset() {
+	*x = ScanReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScanReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanReply) ProtoMessage() {}
+
+func (x *ScanReply) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanReply.ProtoReflect.Descriptor instead.
+func (*ScanReply) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ScanReply) GetInstances() []*Instance {
+	if x != nil {
+		return x.Instances
+	}
+	return nil
+}
+
+func (x *ScanReply) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ScanReply) GetReadPoint() int64 {
+	if x != nil {
+		return x.ReadPoint
+	}
+	return 0
+}
+
+// NamespaceStatsRequest asks an Acceptor for the usage of a namespace.
+type NamespaceStatsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Namespace string `protobuf:"bytes,1,opt,name=Namespace,proto3" json:"Namespace,omitempty"`
+}
+
+func (x *NamespaceStatsRequest) Reset() {
+	*x = NamespaceStatsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NamespaceStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NamespaceStatsRequest) ProtoMessage() {}
+
+func (x *NamespaceStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NamespaceStatsRequest.ProtoReflect.Descriptor instead.
+func (*NamespaceStatsRequest) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *NamespaceStatsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+// NamespaceStatsReply is the usage and quota of a namespace on an Acceptor.
+type NamespaceStatsReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the number of keys stored.
+	Keys int64 `protobuf:"varint,1,opt,name=Keys,proto3" json:"Keys,omitempty"`
+	// the number of versions stored.
+	Versions int64 `protobuf:"varint,2,opt,name=Versions,proto3" json:"Versions,omitempty"`
+	// the bytes of keys and voted values stored.
+	Bytes int64 `protobuf:"varint,3,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
+	// the quota of keys, 0 means no limit.
+	MaxKeys int64 `protobuf:"varint,4,opt,name=MaxKeys,proto3" json:"MaxKeys,omitempty"`
+	// the quota of bytes, 0 means no limit.
+	MaxBytes int64 `protobuf:"varint,5,opt,name=MaxBytes,proto3" json:"MaxBytes,omitempty"`
+}
+
+func (x *NamespaceStatsReply) Reset() {
+	*x = NamespaceStatsReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NamespaceStatsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NamespaceStatsReply) ProtoMessage() {}
+
+func (x *NamespaceStatsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NamespaceStatsReply.ProtoReflect.Descriptor instead.
+func (*NamespaceStatsReply) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *NamespaceStatsReply) GetKeys() int64 {
+	if x != nil {
+		return x.Keys
+	}
+	return 0
+}
+
+func (x *NamespaceStatsReply) GetVersions() int64 {
+	if x != nil {
+		return x.Versions
+	}
+	return 0
+}
+
+func (x *NamespaceStatsReply) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *NamespaceStatsReply) GetMaxKeys() int64 {
+	if x != nil {
+		return x.MaxKeys
+	}
+	return 0
+}
+
+func (x *NamespaceStatsReply) GetMaxBytes() int64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+// LogLevelRequest changes the log level of a subsystem, e.g. acceptor,
+// proposer or transport.
+type LogLevelRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Subsystem string `protobuf:"bytes,1,opt,name=Subsystem,proto3" json:"Subsystem,omitempty"`
+	// the level to change to, the levels are only returned if it is empty.
+	Level string `protobuf:"bytes,2,opt,name=Level,proto3" json:"Level,omitempty"`
+}
+
+func (x *LogLevelRequest) Reset() {
+	*x = LogLevelRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LogLevelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogLevelRequest) ProtoMessage() {}
+
+func (x *LogLevelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogLevelRequest.ProtoReflect.Descriptor instead.
+func (*LogLevelRequest) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *LogLevelRequest) GetSubsystem() string {
+	if x != nil {
+		return x.Subsystem
+	}
+	return ""
+}
+
+func (x *LogLevelRequest) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+// LogLevelReply is the log levels of all the subsystems.
+type LogLevelReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Levels map[string]string `protobuf:"bytes,1,rep,name=Levels,proto3" json:"Levels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *LogLevelReply) Reset() {
+	*x = LogLevelReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LogLevelReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogLevelReply) ProtoMessage() {}
+
+func (x *LogLevelReply) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogLevelReply.ProtoReflect.Descriptor instead.
+func (*LogLevelReply) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *LogLevelReply) GetLevels() map[string]string {
+	if x != nil {
+		return x.Levels
+	}
+	return nil
+}
+
+// AuditRecord is a change of the state of an Acceptor on a paxos instance,
+// i.e. of its `LastBal`, `VBal` or `Val`.
+type AuditRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// which paxos instance is changed.
+	Id *PaxosInstanceId `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	// the request changing the state, Prepare or Accept.
+	Method string `protobuf:"bytes,2,opt,name=Method,proto3" json:"Method,omitempty"`
+	// the state before the change.
+	Old *Acceptor `protobuf:"bytes,3,opt,name=Old,proto3" json:"Old,omitempty"`
+	// the state after the change.
+	New *Acceptor `protobuf:"bytes,4,opt,name=New,proto3" json:"New,omitempty"`
+	// the principal of the caller, empty if the Acceptor has no access control.
+	Caller string `protobuf:"bytes,5,opt,name=Caller,proto3" json:"Caller,omitempty"`
+	// the network address of the caller.
+	Peer string `protobuf:"bytes,6,opt,name=Peer,proto3" json:"Peer,omitempty"`
+	// the unix time in nanoseconds the change is made at.
+	Time int64 `protobuf:"varint,7,opt,name=Time,proto3" json:"Time,omitempty"`
+	// the Acceptor changed.
+	AcceptorId int64 `protobuf:"varint,8,opt,name=AcceptorId,proto3" json:"AcceptorId,omitempty"`
+}
+
+func (x *AuditRecord) Reset() {
+	*x = AuditRecord{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AuditRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditRecord) ProtoMessage() {}
+
+func (x *AuditRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditRecord.ProtoReflect.Descriptor instead.
+func (*AuditRecord) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AuditRecord) GetId() *PaxosInstanceId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *AuditRecord) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *AuditRecord) GetOld() *Acceptor {
+	if x != nil {
+		return x.Old
+	}
+	return nil
+}
+
+func (x *AuditRecord) GetNew() *Acceptor {
+	if x != nil {
+		return x.New
+	}
+	return nil
+}
+
+func (x *AuditRecord) GetCaller() string {
+	if x != nil {
+		return x.Caller
+	}
+	return ""
+}
+
+func (x *AuditRecord) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+func (x *AuditRecord) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *AuditRecord) GetAcceptorId() int64 {
+	if x != nil {
+		return x.AcceptorId
+	}
+	return 0
+}
+
+// AuditRequest asks an Acceptor for the audit records of a key.
+type AuditRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key string `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	// the namespace of the key.
+	Namespace string `protobuf:"bytes,2,opt,name=Namespace,proto3" json:"Namespace,omitempty"`
+	// the first version to return the records of.
+	FromVer int64 `protobuf:"varint,3,opt,name=FromVer,proto3" json:"FromVer,omitempty"`
+}
+
+func (x *AuditRequest) Reset() {
+	*x = AuditRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AuditRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditRequest) ProtoMessage() {}
+
+func (x *AuditRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditRequest.ProtoReflect.Descriptor instead.
+func (*AuditRequest) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AuditRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *AuditRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *AuditRequest) GetFromVer() int64 {
+	if x != nil {
+		return x.FromVer
+	}
+	return 0
+}
+
+// AuditReply is the audit records of a key, in the order they are made.
+type AuditReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Records []*AuditRecord `protobuf:"bytes,1,rep,name=Records,proto3" json:"Records,omitempty"`
+}
+
+func (x *AuditReply) Reset() {
+	*x = AuditReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AuditReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditReply) ProtoMessage() {}
+
+func (x *AuditReply) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditReply.ProtoReflect.Descriptor instead.
+func (*AuditReply) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *AuditReply) GetRecords() []*AuditRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+// StatusRequest asks an Acceptor for its status.
+type StatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{19}
+}
+
+// StatusReply is the status of an Acceptor.
+type StatusReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AcceptorId int64 `protobuf:"varint,1,opt,name=AcceptorId,proto3" json:"AcceptorId,omitempty"`
+	// the nanoseconds since the Acceptor was created.
+	Uptime int64 `protobuf:"varint,2,opt,name=Uptime,proto3" json:"Uptime,omitempty"`
+	// whether the Acceptor is ready to serve paxos requests.
+	Ready bool `protobuf:"varint,3,opt,name=Ready,proto3" json:"Ready,omitempty"`
+	// the bytes of keys and voted values stored of all namespaces.
+	Bytes int64 `protobuf:"varint,4,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
+	// the number of keys stored of all namespaces.
+	Keys int64 `protobuf:"varint,5,opt,name=Keys,proto3" json:"Keys,omitempty"`
+	// the number of versions stored of all namespaces.
+	Versions int64 `protobuf:"varint,6,opt,name=Versions,proto3" json:"Versions,omitempty"`
+	// the position of the last instance appended to the commit log, 0 if none.
+	LastIndex int64 `protobuf:"varint,7,opt,name=LastIndex,proto3" json:"LastIndex,omitempty"`
+}
+
+func (x *StatusReply) Reset() {
+	*x = StatusReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatusReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusReply) ProtoMessage() {}
+
+func (x *StatusReply) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusReply.ProtoReflect.Descriptor instead.
+func (*StatusReply) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *StatusReply) GetAcceptorId() int64 {
+	if x != nil {
+		return x.AcceptorId
+	}
+	return 0
+}
+
+func (x *StatusReply) GetUptime() int64 {
+	if x != nil {
+		return x.Uptime
+	}
+	return 0
+}
+
+func (x *StatusReply) GetReady() bool {
+	if x != nil {
+		return x.Ready
+	}
+	return false
+}
+
+func (x *StatusReply) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *StatusReply) GetKeys() int64 {
+	if x != nil {
+		return x.Keys
+	}
+	return 0
+}
+
+func (x *StatusReply) GetVersions() int64 {
+	if x != nil {
+		return x.Versions
+	}
+	return 0
+}
+
+func (x *StatusReply) GetLastIndex() int64 {
+	if x != nil {
+		return x.LastIndex
+	}
+	return 0
+}
+
+// FenceRequest raises the fence of an Acceptor to an epoch. Prepare and
+// Accept requests of an epoch lower than the fence are rejected.
+type FenceRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Epoch int64 `protobuf:"varint,1,opt,name=Epoch,proto3" json:"Epoch,omitempty"`
+}
+
+func (x *FenceRequest) Reset() {
+	*x = FenceRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FenceRequest) ProtoMessage() {}
+
+func (x *FenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FenceRequest.ProtoReflect.Descriptor instead.
+func (*FenceRequest) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *FenceRequest) GetEpoch() int64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+// FenceReply is the fence of an Acceptor after a Fence request.
+type FenceReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Epoch int64 `protobuf:"varint,1,opt,name=Epoch,proto3" json:"Epoch,omitempty"`
+}
+
+func (x *FenceReply) Reset() {
+	*x = FenceReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_paxos_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FenceReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FenceReply) ProtoMessage() {}
+
+func (x *FenceReply) ProtoReflect() protoreflect.Message {
+	mi := &file_api_paxos_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FenceReply.ProtoReflect.Descriptor instead.
+func (*FenceReply) Descriptor() ([]byte, []int) {
+	return file_api_paxos_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *FenceReply) GetEpoch() int64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+var File_api_paxos_proto protoreflect.FileDescriptor
+
+var file_api_paxos_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x04, 0x63, 0x6f, 0x72, 0x65, 0x22, 0x39, 0x0a, 0x09, 0x42, 0x61, 0x6c, 0x6c, 0x6f,
+	0x74, 0x4e, 0x75, 0x6d, 0x12, 0x0c, 0x0a, 0x01, 0x4e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x01, 0x4e, 0x12, 0x1e, 0x0a, 0x0a, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x49, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72,
+	0x49, 0x64, 0x22, 0x7f, 0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x56,
+	0x69, 0x36, 0x34, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x56, 0x69, 0x36, 0x34, 0x12,
+	0x1c, 0x0a, 0x09, 0x54, 0x6f, 0x6d, 0x62, 0x73, 0x74, 0x6f, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x09, 0x54, 0x6f, 0x6d, 0x62, 0x73, 0x74, 0x6f, 0x6e, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x41, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x08, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x41, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x78, 0x6e,
+	0x49, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x54, 0x78, 0x6e, 0x49, 0x64, 0x12,
+	0x12, 0x0a, 0x04, 0x44, 0x61, 0x74, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x44,
+	0x61, 0x74, 0x61, 0x22, 0x53, 0x0a, 0x0f, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x56, 0x65, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x56, 0x65, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x4e, 0x61,
+	0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x4e,
+	0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x22, 0x79, 0x0a, 0x08, 0x41, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x6f, 0x72, 0x12, 0x29, 0x0a, 0x07, 0x6c, 0x61, 0x73, 0x74, 0x42, 0x61, 0x6c, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x61, 0x6c,
+	0x6c, 0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x52, 0x07, 0x6c, 0x61, 0x73, 0x74, 0x42, 0x61, 0x6c, 0x12,
+	0x1d, 0x0a, 0x03, 0x76, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x76, 0x61, 0x6c, 0x12, 0x23,
+	0x0a, 0x04, 0x56, 0x42, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x42, 0x61, 0x6c, 0x6c, 0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x52, 0x04, 0x56,
+	0x42, 0x61, 0x6c, 0x22, 0x89, 0x01, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72,
+	0x12, 0x25, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63,
+	0x65, 0x49, 0x64, 0x52, 0x02, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x03, 0x42, 0x61, 0x6c, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x61, 0x6c, 0x6c,
+	0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x52, 0x03, 0x42, 0x61, 0x6c, 0x12, 0x1d, 0x0a, 0x03, 0x56, 0x61,
+	0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x56,
+	0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x56, 0x61, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x45, 0x70, 0x6f,
+	0x63, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x22,
+	0x66, 0x0a, 0x08, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x02, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x50,
+	0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x52, 0x02,
+	0x49, 0x64, 0x12, 0x1d, 0x0a, 0x03, 0x56, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x56, 0x61,
+	0x6c, 0x12, 0x14, 0x0a, 0x05, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x05, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x22, 0x30, 0x0a, 0x10, 0x53, 0x75, 0x62, 0x73, 0x63,
+	0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x46,
+	0x72, 0x6f, 0x6d, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
+	0x46, 0x72, 0x6f, 0x6d, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x22, 0x70, 0x0a, 0x0c, 0x57, 0x61, 0x74,
+	0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x4b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x4b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x50,
+	0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x50, 0x72, 0x65,
+	0x66, 0x69, 0x78, 0x12, 0x18, 0x0a, 0x07, 0x46, 0x72, 0x6f, 0x6d, 0x56, 0x65, 0x72, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x46, 0x72, 0x6f, 0x6d, 0x56, 0x65, 0x72, 0x12, 0x1c, 0x0a,
+	0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x22, 0x46, 0x0a, 0x14, 0x4c,
+	0x61, 0x74, 0x65, 0x73, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x4b, 0x65, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x22, 0x6a, 0x0a, 0x12, 0x4c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x56, 0x6f, 0x74,
+	0x65, 0x64, 0x56, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x56, 0x6f, 0x74,
+	0x65, 0x64, 0x56, 0x65, 0x72, 0x12, 0x22, 0x0a, 0x0c, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74,
+	0x65, 0x64, 0x56, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x43, 0x6f, 0x6d,
+	0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x56, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x46, 0x6c, 0x6f,
+	0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x46, 0x6c, 0x6f, 0x6f, 0x72, 0x22,
+	0xe7, 0x01, 0x0a, 0x0b, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x1a, 0x0a, 0x08, 0x53, 0x74, 0x61, 0x72, 0x74, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x53, 0x74, 0x61, 0x72, 0x74, 0x4b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x45,
+	0x6e, 0x64, 0x4b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x45, 0x6e, 0x64,
+	0x4b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x05, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x61, 0x67,
+	0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x50, 0x61,
+	0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x52, 0x65, 0x61, 0x64, 0x50,
+	0x6f, 0x69, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x52, 0x65, 0x61, 0x64,
+	0x50, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x54, 0x6f, 0x6d, 0x62, 0x73, 0x74, 0x6f,
+	0x6e, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x54, 0x6f, 0x6d, 0x62, 0x73,
+	0x74, 0x6f, 0x6e, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x6f, 0x74, 0x65, 0x64, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x05, 0x56, 0x6f, 0x74, 0x65, 0x64, 0x22, 0x7d, 0x0a, 0x09, 0x53, 0x63, 0x61,
+	0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2c, 0x0a, 0x09, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e,
+	0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x63, 0x6f, 0x72, 0x65,
+	0x2e, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x09, 0x49, 0x6e, 0x73, 0x74, 0x61,
+	0x6e, 0x63, 0x65, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x4e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x65,
+	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x4e, 0x65, 0x78,
+	0x74, 0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x52, 0x65,
+	0x61, 0x64, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x52,
+	0x65, 0x61, 0x64, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x22, 0x35, 0x0a, 0x15, 0x4e, 0x61, 0x6d, 0x65,
+	0x73, 0x70, 0x61, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x1c, 0x0a, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x22,
+	0x91, 0x01, 0x0a, 0x13, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x53, 0x74, 0x61,
+	0x74, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x4b, 0x65, 0x79, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x42, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x18, 0x0a,
+	0x07, 0x4d, 0x61, 0x78, 0x4b, 0x65, 0x79, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07,
+	0x4d, 0x61, 0x78, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x4d, 0x61, 0x78, 0x42, 0x79,
+	0x74, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x4d, 0x61, 0x78, 0x42, 0x79,
+	0x74, 0x65, 0x73, 0x22, 0x45, 0x0a, 0x0f, 0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x53, 0x75, 0x62, 0x73, 0x79, 0x73,
+	0x74, 0x65, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x53, 0x75, 0x62, 0x73, 0x79,
+	0x73, 0x74, 0x65, 0x6d, 0x12, 0x14, 0x0a, 0x05, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x22, 0x83, 0x01, 0x0a, 0x0d, 0x4c,
+	0x6f, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x37, 0x0a, 0x06,
+	0x4c, 0x65, 0x76, 0x65, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x2e, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x4c,
+	0x65, 0x76, 0x65, 0x6c, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01,
+	0x22, 0xf0, 0x01, 0x0a, 0x0b, 0x41, 0x75, 0x64, 0x69, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64,
+	0x12, 0x25, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63,
+	0x65, 0x49, 0x64, 0x52, 0x02, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x4d, 0x65, 0x74, 0x68, 0x6f,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12,
+	0x20, 0x0a, 0x03, 0x4f, 0x6c, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x52, 0x03, 0x4f, 0x6c,
+	0x64, 0x12, 0x20, 0x0a, 0x03, 0x4e, 0x65, 0x77, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e,
+	0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x52, 0x03,
+	0x4e, 0x65, 0x77, 0x12, 0x16, 0x0a, 0x06, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x50,
+	0x65, 0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x50, 0x65, 0x65, 0x72, 0x12,
+	0x12, 0x0a, 0x04, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x54,
+	0x69, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x49,
+	0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f,
+	0x72, 0x49, 0x64, 0x22, 0x58, 0x0a, 0x0c, 0x41, 0x75, 0x64, 0x69, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x4b, 0x65, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x46, 0x72, 0x6f, 0x6d, 0x56, 0x65, 0x72, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x46, 0x72, 0x6f, 0x6d, 0x56, 0x65, 0x72, 0x22, 0x39, 0x0a,
+	0x0a, 0x41, 0x75, 0x64, 0x69, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2b, 0x0a, 0x07, 0x52,
+	0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75, 0x64, 0x69, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52,
+	0x07, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x22, 0x0f, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xbf, 0x01, 0x0a, 0x0b, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1e, 0x0a, 0x0a, 0x41, 0x63, 0x63,
+	0x65, 0x70, 0x74, 0x6f, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x41,
+	0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x55, 0x70, 0x74,
+	0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x55, 0x70, 0x74, 0x69, 0x6d,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x52, 0x65, 0x61, 0x64, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x05, 0x52, 0x65, 0x61, 0x64, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x42, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x12, 0x0a,
+	0x04, 0x4b, 0x65, 0x79, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x4b, 0x65, 0x79,
+	0x73, 0x12, 0x1a, 0x0a, 0x08, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x08, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1c, 0x0a,
+	0x09, 0x4c, 0x61, 0x73, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x4c, 0x61, 0x73, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x22, 0x24, 0x0a, 0x0c, 0x46,
+	0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x45,
+	0x70, 0x6f, 0x63, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x45, 0x70, 0x6f, 0x63,
+	0x68, 0x22, 0x22, 0x0a, 0x0a, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05,
+	0x45, 0x70, 0x6f, 0x63, 0x68, 0x32, 0x8b, 0x05, 0x0a, 0x07, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x4b,
+	0x56, 0x12, 0x2b, 0x0a, 0x07, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x12, 0x0e, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x1a, 0x0e, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x2a,
+	0x0a, 0x06, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x12, 0x0e, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
+	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x1a, 0x0e, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
+	0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x2a, 0x0a, 0x06, 0x43, 0x6f,
+	0x6d, 0x6d, 0x69, 0x74, 0x12, 0x0e, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x70,
+	0x6f, 0x73, 0x65, 0x72, 0x1a, 0x0e, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x37, 0x0a, 0x09, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72,
+	0x69, 0x62, 0x65, 0x12, 0x16, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63,
+	0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x63, 0x6f,
+	0x72, 0x65, 0x2e, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12,
+	0x2f, 0x0a, 0x05, 0x57, 0x61, 0x74, 0x63, 0x68, 0x12, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
+	0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x22, 0x00, 0x30, 0x01,
+	0x12, 0x47, 0x0a, 0x0d, 0x4c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x1a, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e,
+	0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x2c, 0x0a, 0x04, 0x53, 0x63, 0x61,
+	0x6e, 0x12, 0x11, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x53, 0x63, 0x61, 0x6e,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4a, 0x0a, 0x0e, 0x4e, 0x61, 0x6d, 0x65, 0x73,
+	0x70, 0x61, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x1b, 0x2e, 0x63, 0x6f, 0x72, 0x65,
+	0x2e, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4e, 0x61,
+	0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x12, 0x38, 0x0a, 0x08, 0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12,
+	0x15, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4c, 0x6f,
+	0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x2f, 0x0a,
+	0x05, 0x41, 0x75, 0x64, 0x69, 0x74, 0x12, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75,
+	0x64, 0x69, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x63, 0x6f, 0x72,
+	0x65, 0x2e, 0x41, 0x75, 0x64, 0x69, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x32,
+	0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x13, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e,
+	0x63, 0x6f, 0x72, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79,
+	0x22, 0x00, 0x12, 0x2f, 0x0a, 0x05, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x12, 0x2e, 0x63, 0x6f,
+	0x72, 0x65, 0x2e, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x10, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x2f, 0x63, 0x6f, 0x72, 0x65, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_api_paxos_proto_rawDescOnce sync.Once
+	file_api_paxos_proto_rawDescData = file_api_paxos_proto_rawDesc
+)
+
+func file_api_paxos_proto_rawDescGZIP() []byte {
+	file_api_paxos_proto_rawDescOnce.Do(func() {
+		file_api_paxos_proto_rawDescData = protoimpl.X.CompressGZIP(file_api_paxos_proto_rawDescData)
+	})
+	return file_api_paxos_proto_rawDescData
+}
+
+var file_api_paxos_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
+var file_api_paxos_proto_goTypes = []interface{}{
+	(*BallotNum)(nil),             // 0: core.BallotNum
+	(*Value)(nil),                 // 1: core.Value
+	(*PaxosInstanceId)(nil),       // 2: core.PaxosInstanceId
+	(*Acceptor)(nil),              // 3: core.Acceptor
+	(*Proposer)(nil),              // 4: core.Proposer
+	(*Instance)(nil),              // 5: core.Instance
+	(*SubscribeRequest)(nil),      // 6: core.SubscribeRequest
+	(*WatchRequest)(nil),          // 7: core.WatchRequest
+	(*LatestVersionRequest)(nil),  // 8: core.LatestVersionRequest
+	(*LatestVersionReply)(nil),    // 9: core.LatestVersionReply
+	(*ScanRequest)(nil),           // 10: core.ScanRequest
+	(*ScanReply)(nil),             // 11: core.ScanReply
+	(*NamespaceStatsRequest)(nil), // 12: core.NamespaceStatsRequest
+	(*NamespaceStatsReply)(nil),   // 13: core.NamespaceStatsReply
+	(*LogLevelRequest)(nil),       // 14: core.LogLevelRequest
+	(*LogLevelReply)(nil),         // 15: core.LogLevelReply
+	(*AuditRecord)(nil),           // 16: core.AuditRecord
+	(*AuditRequest)(nil),          // 17: core.AuditRequest
+	(*AuditReply)(nil),            // 18: core.AuditReply
+	(*StatusRequest)(nil),         // 19: core.StatusRequest
+	(*StatusReply)(nil),           // 20: core.StatusReply
+	(*FenceRequest)(nil),          // 21: core.FenceRequest
+	(*FenceReply)(nil),            // 22: core.FenceReply
+	nil,                           // 23: core.LogLevelReply.LevelsEntry
+}
+var file_api_paxos_proto_depIdxs = []int32{
+	0,  // 0: core.Acceptor.lastBal:type_name -> core.BallotNum
+	1,  // 1: core.Acceptor.val:type_name -> core.Value
+	0,  // 2: core.Acceptor.VBal:type_name -> core.BallotNum
+	2,  // 3: core.Proposer.Id:type_name -> core.PaxosInstanceId
+	0,  // 4: core.Proposer.Bal:type_name -> core.BallotNum
+	1,  // 5: core.Proposer.Val:type_name -> core.Value
+	2,  // 6: core.Instance.Id:type_name -> core.PaxosInstanceId
+	1,  // 7: core.Instance.Val:type_name -> core.Value
+	5,  // 8: core.ScanReply.Instances:type_name -> core.Instance
+	23, // 9: core.LogLevelReply.Levels:type_name -> core.LogLevelReply.LevelsEntry
+	2,  // 10: core.AuditRecord.Id:type_name -> core.PaxosInstanceId
+	3,  // 11: core.AuditRecord.Old:type_name -> core.Acceptor
+	3,  // 12: core.AuditRecord.New:type_name -> core.Acceptor
+	16, // 13: core.AuditReply.Records:type_name -> core.AuditRecord
+	4,  // 14: core.PaxosKV.Prepare:input_type -> core.Proposer
+	4,  // 15: core.PaxosKV.Accept:input_type -> core.Proposer
+	4,  // 16: core.PaxosKV.Commit:input_type -> core.Proposer
+	6,  // 17: core.PaxosKV.Subscribe:input_type -> core.SubscribeRequest
+	7,  // 18: core.PaxosKV.Watch:input_type -> core.WatchRequest
+	8,  // 19: core.PaxosKV.LatestVersion:input_type -> core.LatestVersionRequest
+	10, // 20: core.PaxosKV.Scan:input_type -> core.ScanRequest
+	12, // 21: core.PaxosKV.NamespaceStats:input_type -> core.NamespaceStatsRequest
+	14, // 22: core.PaxosKV.LogLevel:input_type -> core.LogLevelRequest
+	17, // 23: core.PaxosKV.Audit:input_type -> core.AuditRequest
+	19, // 24: core.PaxosKV.Status:input_type -> core.StatusRequest
+	21, // 25: core.PaxosKV.Fence:input_type -> core.FenceRequest
+	3,  // 26: core.PaxosKV.Prepare:output_type -> core.Acceptor
+	3,  // 27: core.PaxosKV.Accept:output_type -> core.Acceptor
+	3,  // 28: core.PaxosKV.Commit:output_type -> core.Acceptor
+	5,  // 29: core.PaxosKV.Subscribe:output_type -> core.Instance
+	5,  // 30: core.PaxosKV.Watch:output_type -> core.Instance
+	9,  // 31: core.PaxosKV.LatestVersion:output_type -> core.LatestVersionReply
+	11, // 32: core.PaxosKV.Scan:output_type -> core.ScanReply
+	13, // 33: core.PaxosKV.NamespaceStats:output_type -> core.NamespaceStatsReply
+	15, // 34: core.PaxosKV.LogLevel:output_type -> core.LogLevelReply
+	18, // 35: core.PaxosKV.Audit:output_type -> core.AuditReply
+	20, // 36: core.PaxosKV.Status:output_type -> core.StatusReply
+	22, // 37: core.PaxosKV.Fence:output_type -> core.FenceReply
+	26, // [26:38] is the sub-list for method output_type
+	14, // [14:26] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_api_paxos_proto_init() }
+func file_api_paxos_proto_init() {
+	if File_api_paxos_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_api_paxos_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BallotNum); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Value); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PaxosInstanceId); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Acceptor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Proposer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Instance); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubscribeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LatestVersionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LatestVersionReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScanRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScanReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NamespaceStatsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NamespaceStatsReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LogLevelRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LogLevelReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuditRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuditRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuditReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatusReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FenceRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_paxos_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FenceReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_api_paxos_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   24,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_paxos_proto_goTypes,
+		DependencyIndexes: file_api_paxos_proto_depIdxs,
+		MessageInfos:      file_api_paxos_proto_msgTypes,
+	}.Build()
+	File_api_paxos_proto = out.File
+	file_api_paxos_proto_rawDesc = nil
+	file_api_paxos_proto_goTypes = nil
+	file_api_paxos_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// PaxosKVClient is the client API for PaxosKV service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PaxosKVClient interface {
+	Prepare(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error)
+	Accept(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error)
+	Commit(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (PaxosKV_SubscribeClient, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (PaxosKV_WatchClient, error)
+	LatestVersion(ctx context.Context, in *LatestVersionRequest, opts ...grpc.CallOption) (*LatestVersionReply, error)
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanReply, error)
+	NamespaceStats(ctx context.Context, in *NamespaceStatsRequest, opts ...grpc.CallOption) (*NamespaceStatsReply, error)
+	LogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*LogLevelReply, error)
+	Audit(ctx context.Context, in *AuditRequest, opts ...grpc.CallOption) (*AuditReply, error)
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusReply, error)
+	Fence(ctx context.Context, in *FenceRequest, opts ...grpc.CallOption) (*FenceReply, error)
+}
+
+type paxosKVClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPaxosKVClient(cc grpc.ClientConnInterface) PaxosKVClient {
+	return &paxosKVClient{cc}
+}
+
+func (c *paxosKVClient) Prepare(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error) {
+	out := new(Acceptor)
+	err := c.cc.Invoke(ctx, "/core.PaxosKV/Prepare", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) Accept(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error) {
+	out := new(Acceptor)
+	err := c.cc.Invoke(ctx, "/core.PaxosKV/Accept", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) Commit(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error) {
+	out := new(Acceptor)
+	err := c.cc.Invoke(ctx, "/core.PaxosKV/Commit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (PaxosKV_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_PaxosKV_serviceDesc.Streams[0], "/core.PaxosKV/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &paxosKVSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PaxosKV_SubscribeClient interface {
+	Recv() (*Instance, error)
+	grpc.ClientStream
+}
+
+type paxosKVSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *paxosKVSubscribeClient) Recv() (*Instance, error) {
+	m := new(Instance)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *paxosKVClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (PaxosKV_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_PaxosKV_serviceDesc.Streams[1], "/core.PaxosKV/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &paxosKVWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PaxosKV_WatchClient interface {
+	Recv() (*Instance, error)
+	grpc.ClientStream
+}
+
+type paxosKVWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *paxosKVWatchClient) Recv() (*Instance, error) {
+	m := new(Instance)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *paxosKVClient) LatestVersion(ctx context.Context, in *LatestVersionRequest, opts ...grpc.CallOption) (*LatestVersionReply, error) {
+	out := new(LatestVersionReply)
+	err := c.cc.Invoke(ctx, "/core.PaxosKV/LatestVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanReply, error) {
+	out := new(ScanReply)
+	err := c.cc.Invoke(ctx, "/core.PaxosKV/Scan", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) NamespaceStats(ctx context.Context, in *NamespaceStatsRequest, opts ...grpc.CallOption) (*NamespaceStatsReply, error) {
+	out := new(NamespaceStatsReply)
+	err := c.cc.Invoke(ctx, "/core.PaxosKV/NamespaceStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) LogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*LogLevelReply, error) {
+	out := new(LogLevelReply)
+	err := c.cc.Invoke(ctx, "/core.PaxosKV/LogLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) Audit(ctx context.Context, in *AuditRequest, opts ...grpc.CallOption) (*AuditReply, error) {
+	out := new(AuditReply)
+	err := c.cc.Invoke(ctx, "/core.PaxosKV/Audit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusReply, error) {
+	out := new(StatusReply)
+	err := c.cc.Invoke(ctx, "/core.PaxosKV/Status", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) Fence(ctx context.Context, in *FenceRequest, opts ...grpc.CallOption) (*FenceReply, error) {
+	out := new(FenceReply)
+	err := c.cc.Invoke(ctx, "/core.PaxosKV/Fence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PaxosKVServer is the server API for PaxosKV service.
+type PaxosKVServer interface {
+	Prepare(context.Context, *Proposer) (*Acceptor, error)
+	Accept(context.Context, *Proposer) (*Acceptor, error)
+	Commit(context.Context, *Proposer) (*Acceptor, error)
+	Subscribe(*SubscribeRequest, PaxosKV_SubscribeServer) error
+	Watch(*WatchRequest, PaxosKV_WatchServer) error
+	LatestVersion(context.Context, *LatestVersionRequest) (*LatestVersionReply, error)
+	Scan(context.Context, *ScanRequest) (*ScanReply, error)
+	NamespaceStats(context.Context, *NamespaceStatsRequest) (*NamespaceStatsReply, error)
+	LogLevel(context.Context, *LogLevelRequest) (*LogLevelReply, error)
+	Audit(context.Context, *AuditRequest) (*AuditReply, error)
+	Status(context.Context, *StatusRequest) (*StatusReply, error)
+	Fence(context.Context, *FenceRequest) (*FenceReply, error)
+}
+
+// UnimplementedPaxosKVServer can be embedded to have forward compatible implementations.
+type UnimplementedPaxosKVServer struct {
+}
+
+func (*UnimplementedPaxosKVServer) Prepare(context.Context, *Proposer) (*Acceptor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Prepare not implemented")
+}
+func (*UnimplementedPaxosKVServer) Accept(context.Context, *Proposer) (*Acceptor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Accept not implemented")
+}
+func (*UnimplementedPaxosKVServer) Commit(context.Context, *Proposer) (*Acceptor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Commit not implemented")
+}
+func (*UnimplementedPaxosKVServer) Subscribe(*SubscribeRequest, PaxosKV_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (*UnimplementedPaxosKVServer) Watch(*WatchRequest, PaxosKV_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (*UnimplementedPaxosKVServer) LatestVersion(context.Context, *LatestVersionRequest) (*LatestVersionReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LatestVersion not implemented")
+}
+func (*UnimplementedPaxosKVServer) Scan(context.Context, *ScanRequest) (*ScanReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Scan not implemented")
+}
+func (*UnimplementedPaxosKVServer) NamespaceStats(context.Context, *NamespaceStatsRequest) (*NamespaceStatsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NamespaceStats not implemented")
+}
+func (*UnimplementedPaxosKVServer) LogLevel(context.Context, *LogLevelRequest) (*LogLevelReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LogLevel not implemented")
+}
+func (*UnimplementedPaxosKVServer) Audit(context.Context, *AuditRequest) (*AuditReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Audit not implemented")
+}
+func (*UnimplementedPaxosKVServer) Status(context.Context, *StatusRequest) (*StatusReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (*UnimplementedPaxosKVServer) Fence(context.Context, *FenceRequest) (*FenceReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Fence not implemented")
+}
+
+func RegisterPaxosKVServer(s *grpc.Server, srv PaxosKVServer) {
+	s.RegisterService(&_PaxosKV_serviceDesc, srv)
+}
+
+func _PaxosKV_Prepare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Proposer)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).Prepare(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
@@ -586,6 +2458,192 @@ func _PaxosKV_Accept_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaxosKV_Commit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Proposer)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).Commit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/core.PaxosKV/Commit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).Commit(ctx, req.(*Proposer))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaxosKV_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaxosKVServer).Subscribe(m, &paxosKVSubscribeServer{stream})
+}
+
+type PaxosKV_SubscribeServer interface {
+	Send(*Instance) error
+	grpc.ServerStream
+}
+
+type paxosKVSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *paxosKVSubscribeServer) Send(m *Instance) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PaxosKV_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaxosKVServer).Watch(m, &paxosKVWatchServer{stream})
+}
+
+type PaxosKV_WatchServer interface {
+	Send(*Instance) error
+	grpc.ServerStream
+}
+
+type paxosKVWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *paxosKVWatchServer) Send(m *Instance) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PaxosKV_LatestVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LatestVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).LatestVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/core.PaxosKV/LatestVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).LatestVersion(ctx, req.(*LatestVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaxosKV_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/core.PaxosKV/Scan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).Scan(ctx, req.(*ScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaxosKV_NamespaceStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NamespaceStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).NamespaceStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/core.PaxosKV/NamespaceStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).NamespaceStats(ctx, req.(*NamespaceStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaxosKV_LogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).LogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/core.PaxosKV/LogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).LogLevel(ctx, req.(*LogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaxosKV_Audit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).Audit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/core.PaxosKV/Audit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).Audit(ctx, req.(*AuditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaxosKV_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/core.PaxosKV/Status",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaxosKV_Fence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).Fence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/core.PaxosKV/Fence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).Fence(ctx, req.(*FenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _PaxosKV_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "core.PaxosKV",
 	HandlerType: (*PaxosKVServer)(nil),
@@ -598,7 +2656,50 @@ var _PaxosKV_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Accept",
 			Handler:    _PaxosKV_Accept_Handler,
 		},
+		{
+			MethodName: "Commit",
+			Handler:    _PaxosKV_Commit_Handler,
+		},
+		{
+			MethodName: "LatestVersion",
+			Handler:    _PaxosKV_LatestVersion_Handler,
+		},
+		{
+			MethodName: "Scan",
+			Handler:    _PaxosKV_Scan_Handler,
+		},
+		{
+			MethodName: "NamespaceStats",
+			Handler:    _PaxosKV_NamespaceStats_Handler,
+		},
+		{
+			MethodName: "LogLevel",
+			Handler:    _PaxosKV_LogLevel_Handler,
+		},
+		{
+			MethodName: "Audit",
+			Handler:    _PaxosKV_Audit_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _PaxosKV_Status_Handler,
+		},
+		{
+			MethodName: "Fence",
+			Handler:    _PaxosKV_Fence_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _PaxosKV_Subscribe_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _PaxosKV_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/paxos.proto",
 }
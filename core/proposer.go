@@ -4,11 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // @Author KHighness
@@ -16,69 +22,208 @@ import (
 
 var (
 	ErrNoEnoughQuorum = errors.New("no enough quorum")
+	ErrNoAcceptors    = errors.New("no acceptors")
 	AcceptorBasePort  = 3333
 )
 
+var (
+	// ProposeBackoff is the longest a Proposer waits before the first retry
+	// of a round with a higher ballot, it doubles with every retry.
+	ProposeBackoff = 5 * time.Millisecond
+	// ProposeMaxBackoff is the longest a Proposer waits before any retry.
+	ProposeMaxBackoff = 200 * time.Millisecond
+)
+
 // RunPaxos executes the paxos phase-1 and phase-2 to establish a value.
 // It returns the established value, which may be a voted value that is not `val`.
 //
 // If `val` is not nil, it acts as a writing operation.
 // If `val` is nil, it acts as a reading operation.
+//
+// It returns nil if Acceptors reject the request for good, see Propose.
 func (p *Proposer) RunPaxos(acceptorIds []int64, val *Value) *Value {
+	v, err := p.Propose(context.Background(), acceptorIds, val)
+	if err != nil {
+		proposerLog.Errorf("Proposer: paxos rejected by Acceptors: %v", err)
+	}
+	return v
+}
+
+// Propose is RunPaxos reporting the error for which Acceptors reject the
+// request, if retrying with a higher ballot would not help, e.g. the version
+// has been reclaimed or the quota of the namespace is exceeded. It returns
+// ErrNoAcceptors if acceptorIds is empty.
+//
+// A value found voted by a quorum at one ballot in phase-1 has been chosen,
+// it is returned without phase-2, so that reading a key takes no Accept.
+//
+// A round failing for a higher ballot is retried after a random delay up to
+// ProposeBackoff, doubled with every retry, so that competing Proposers do
+// not keep preempting each other. It gives up retrying and returns the error
+// of ctx once ctx is done.
+func (p *Proposer) Propose(ctx context.Context, acceptorIds []int64, val *Value) (*Value, error) {
+	return p.ProposeWith(ctx, nil, acceptorIds, val)
+}
+
+// ProposeWith is Propose sending the requests through the Transport, gRPC if
+// it is nil.
+func (p *Proposer) ProposeWith(ctx context.Context, t Transport, acceptorIds []int64, val *Value) (chosen *Value, err error) {
+	t = OrGRPC(t)
+
+	ctx, span := tracer.Start(ctx, "RunPaxos", trace.WithAttributes(instanceAttributes(p)...))
+	defer func() {
+		span.SetAttributes(attribute.Bool("paxos.chosen", chosen != nil))
+		endSpan(span, err)
+	}()
+
+	if len(acceptorIds) == 0 {
+		proposerProposals.WithLabelValues("rejected").Inc()
+		return nil, ErrNoAcceptors
+	}
 	quorum := len(acceptorIds)/2 + 1
+	start := time.Now()
 
-	for {
+	for rounds := 1; ; rounds++ {
+		if rounds > 1 {
+			p.backoff(ctx, t, rounds-1)
+		}
+		if err := ctx.Err(); err != nil {
+			proposerProposals.WithLabelValues("rejected").Inc()
+			return nil, err
+		}
 		p.Val = nil
+		span.SetAttributes(attribute.Int("paxos.rounds", rounds))
 
-		maxVotedVal, higherBal, err := p.Phase1(acceptorIds, quorum)
-		if err != nil {
-			zap.S().Error("Proposer: failed to run phase-1, highest ballot: %v, increment ballot and retry", higherBal)
-			p.Bal.N = higherBal.N + 1
+		maxVoted, chosen, higherBal, err := p.phase1(ctx, t, acceptorIds, quorum)
+		if err == ErrNoEnoughQuorum {
+			p.bumpBallot("phase-1", higherBal)
 			continue
 		}
+		if err != nil {
+			proposerProposals.WithLabelValues("rejected").Inc()
+			return nil, err
+		}
 
-		if maxVotedVal == nil {
-			zap.S().Infof("Proposer: no voted value seen, propose my value: %v", val)
-		} else {
+		// A value voted by a quorum at one ballot has been chosen, phase-2
+		// would choose it again.
+		if chosen {
+			if ce := proposerLogger.Check(zap.DebugLevel, "Proposer: value chosen before"); ce != nil {
+				ce.Write(instanceFields(p.Id, p.Bal, zap.Int("rounds", rounds), zap.Duration("latency", time.Since(start)))...)
+			}
+			p.Val = maxVoted.Val
+			voted := &Proposer{Id: p.Id, Bal: maxVoted.VBal, Val: maxVoted.Val, Epoch: p.Epoch}
+			voted.commit(ctx, t, acceptorIds)
+			proposerProposals.WithLabelValues("chosen").Inc()
+			proposerRounds.Observe(float64(rounds))
+			return p.Val, nil
+		}
+
+		// A value voted by others must be proposed instead of my value.
+		maxVotedVal := maxVoted.Val
+		if maxVotedVal != nil {
 			val = maxVotedVal
 		}
 
 		if val == nil {
-			zap.S().Infof("Proposer: no value to propose in phase-2, quit")
-			return nil
+			if ce := proposerLogger.Check(zap.DebugLevel, "Proposer: no value to propose in phase-2, quit"); ce != nil {
+				ce.Write(instanceFields(p.Id, p.Bal, zap.Int("rounds", rounds))...)
+			}
+			proposerProposals.WithLabelValues("empty").Inc()
+			return nil, nil
 		}
 
 		p.Val = val
-		zap.S().Infof("Proposer: proposer chose value to propose: %s", p.Val)
+		if ce := proposerLogger.Check(zap.DebugLevel, "Proposer: value to propose in phase-2"); ce != nil {
+			ce.Write(instanceFields(p.Id, p.Bal, zap.Bool("voted_by_others", maxVotedVal != nil))...)
+		}
 
-		higherBal, err = p.Phase2(acceptorIds, quorum)
-		if err != nil {
-			zap.S().Infof("Proposer: failed to run phase-2, highest ballot: %v, increment ballot and retry", higherBal)
-			p.Bal.N = higherBal.N + 1
+		higherBal, err = p.phase2(ctx, t, acceptorIds, quorum)
+		if err == ErrNoEnoughQuorum {
+			p.bumpBallot("phase-2", higherBal)
 			continue
 		}
+		if err != nil {
+			proposerProposals.WithLabelValues("rejected").Inc()
+			return nil, err
+		}
+
+		if ce := proposerLogger.Check(zap.DebugLevel, "Proposer: value chosen"); ce != nil {
+			ce.Write(instanceFields(p.Id, p.Bal, zap.Int("rounds", rounds), zap.Duration("latency", time.Since(start)))...)
+		}
+		p.commit(ctx, t, acceptorIds)
+		proposerProposals.WithLabelValues("chosen").Inc()
+		proposerRounds.Observe(float64(rounds))
+		return p.Val, nil
+	}
+}
+
+// bumpBallot raises the ballot above the highest one seen to retry the round.
+func (p *Proposer) bumpBallot(phase string, higherBal *BallotNum) {
+	if ce := proposerLogger.Check(zap.DebugLevel, "Proposer: no quorum, retry with a higher ballot"); ce != nil {
+		ce.Write(instanceFields(p.Id, p.Bal, zap.String("phase", phase), zap.Int64("higher_ballot", higherBal.N))...)
+	}
+	proposerRetries.WithLabelValues(phase).Inc()
+	if higherBal.N >= p.Bal.N {
+		proposerBallotBumps.Inc()
+	}
+	p.Bal.N = higherBal.N + 1
+}
+
+// sleeper is implemented by a Transport simulating time, on which a Proposer
+// waits in simulated time.
+type sleeper interface {
+	Sleep(d time.Duration)
+}
+
+// backoff waits a random delay before the retry, until ctx is done. The delay
+// is drawn from the ballot, so that Proposers of different ids wait
+// differently, and a simulation waits the same every time it is replayed.
+func (p *Proposer) backoff(ctx context.Context, t Transport, retry int) {
+	max := ProposeMaxBackoff
+	if retry < 32 && ProposeBackoff<<(retry-1) < max {
+		max = ProposeBackoff << (retry - 1)
+	}
+	d := time.Duration(rand.New(rand.NewSource(p.Bal.N<<16 ^ p.Bal.ProposerId)).Int63n(int64(max) + 1))
 
-		zap.S().Infof("Proposer: value is voted by a quorum and has been safe: %v", p.Val)
-		return p.Val
+	if s, ok := t.(sleeper); ok {
+		s.Sleep(d)
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
 	}
 }
 
 // Phase1 runs paxos phase-1 on the specified acceptorIds.
 // If a higher ballot number is seen and phase-1 failed to constitute a quorum,
 // the highest ballot and a ErrNoEnoughQuorum will be returned.
+// If too many Acceptors reject the request for good to constitute a quorum,
+// the rejection will be returned, ErrNoAcceptors if there is no Acceptor.
 func (p *Proposer) Phase1(acceptorIds []int64, quorum int) (*Value, *BallotNum, error) {
-	replies := p.rpcToAll(acceptorIds, "Prepare")
+	maxVoted, _, higherBal, err := p.phase1(context.Background(), grpcTransport{}, acceptorIds, quorum)
+	return maxVoted.GetVal(), higherBal, err
+}
 
-	var count int
-	higherBal := *p.Bal
+// phase1 returns the vote of the highest ballot among a quorum promising, and
+// whether the quorum has voted it at the same ballot, i.e. it has been chosen.
+func (p *Proposer) phase1(ctx context.Context, t Transport, acceptorIds []int64, quorum int) (_ *Acceptor, _ bool, _ *BallotNum, err error) {
+	ctx, span := tracer.Start(ctx, "Phase1", trace.WithAttributes(instanceAttributes(p)...))
+	defer func() { endSpan(span, err) }()
+
+	if len(acceptorIds) == 0 {
+		return nil, false, nil, ErrNoAcceptors
+	}
+	replies, rejections := p.rpcToAll(ctx, t, acceptorIds, "Prepare")
+
+	var promised []*Acceptor
+	higherBal := proto.Clone(p.Bal).(*BallotNum)
 	maxVoted := &Acceptor{VBal: &BallotNum{}}
 
 	for _, r := range replies {
-		zap.S().Infof("Proposer: handling Prepare Reply: %v", r)
-
 		if !p.Bal.GE(r.LastBal) {
-			if r.LastBal.GE(&higherBal) {
-				higherBal = *r.LastBal
+			if r.LastBal.GE(higherBal) {
+				higherBal = r.LastBal
 			}
 			continue
 		}
@@ -87,29 +232,46 @@ func (p *Proposer) Phase1(acceptorIds []int64, quorum int) (*Value, *BallotNum,
 			maxVoted = r
 		}
 
-		count += 1
-		if count == quorum {
-			return maxVoted.Val, nil, nil
+		promised = append(promised, r)
+		if len(promised) == quorum {
+			chosen := maxVoted.Val != nil
+			for _, r := range promised {
+				chosen = chosen && proto.Equal(r.VBal, maxVoted.VBal)
+			}
+			return maxVoted, chosen, nil, nil
 		}
 	}
 
-	return nil, &higherBal, ErrNoEnoughQuorum
+	if len(acceptorIds)-len(rejections) < quorum {
+		return nil, false, nil, rejections[0]
+	}
+	return nil, false, higherBal, ErrNoEnoughQuorum
 }
 
 // Phase2 runs paxos phase-2 on the specified acceptorIds.
 // If a higher ballot number is seen and phase-1 failed to constitute a quorum,
 // the highest ballot and a ErrNoEnoughQuorum will be returned.
+// If too many Acceptors reject the request for good to constitute a quorum,
+// the rejection will be returned, ErrNoAcceptors if there is no Acceptor.
 func (p *Proposer) Phase2(acceptorIds []int64, quorum int) (*BallotNum, error) {
-	replies := p.rpcToAll(acceptorIds, "Accept")
+	return p.phase2(context.Background(), grpcTransport{}, acceptorIds, quorum)
+}
+
+func (p *Proposer) phase2(ctx context.Context, t Transport, acceptorIds []int64, quorum int) (_ *BallotNum, err error) {
+	ctx, span := tracer.Start(ctx, "Phase2", trace.WithAttributes(instanceAttributes(p)...))
+	defer func() { endSpan(span, err) }()
+
+	if len(acceptorIds) == 0 {
+		return nil, ErrNoAcceptors
+	}
+	replies, rejections := p.rpcToAll(ctx, t, acceptorIds, "Accept")
 
 	var count int
-	higherBal := *p.Bal
+	higherBal := proto.Clone(p.Bal).(*BallotNum)
 	for _, r := range replies {
-		zap.S().Infof("Proposer: handling Accept reply: %v", r)
-
 		if !p.Bal.GE(r.LastBal) {
-			if r.LastBal.GE(&higherBal) {
-				higherBal = *r.LastBal
+			if r.LastBal.GE(higherBal) {
+				higherBal = r.LastBal
 			}
 			continue
 		}
@@ -120,42 +282,183 @@ func (p *Proposer) Phase2(acceptorIds []int64, quorum int) (*BallotNum, error) {
 		}
 	}
 
-	return &higherBal, ErrNoEnoughQuorum
+	if len(acceptorIds)-len(rejections) < quorum {
+		return nil, rejections[0]
+	}
+	return higherBal, ErrNoEnoughQuorum
 }
 
-// rpcToAll sends Prepare or Accept RPC to the specified Acceptors.
-func (p *Proposer) rpcToAll(acceptorIds []int64, action string) []*Acceptor {
-	var replies []*Acceptor
+// LatestVersion returns the highest version of the key voted by any Acceptor
+// of a quorum, or -1 if there is none.
+//
+// A chosen version has been voted by a quorum, which intersects with the
+// answered quorum, thus no version higher than the returned one is chosen.
+// The returned version itself may not be chosen yet, running paxos on it
+// either finishes it or returns nil.
+func LatestVersion(acceptorIds []int64, key string) (int64, error) {
+	latest, _, err := quorumVersions(context.Background(), grpcTransport{}, acceptorIds, "", key)
+	return latest, err
+}
+
+// NextVersion returns a version of the key which is safe to start writing from,
+// no version at or above it has been chosen or reclaimed.
+func NextVersion(acceptorIds []int64, key string) (int64, error) {
+	return nextVersion(context.Background(), grpcTransport{}, acceptorIds, "", key)
+}
 
+func nextVersion(ctx context.Context, t Transport, acceptorIds []int64, namespace, key string) (int64, error) {
+	latest, floor, err := quorumVersions(ctx, t, acceptorIds, namespace, key)
+	if err != nil {
+		return 0, err
+	}
+	if latest+1 > floor {
+		return latest + 1, nil
+	}
+	return floor, nil
+}
+
+// quorumVersions returns the highest voted version and the highest floor of
+// the key of the namespace answered by a quorum of Acceptors through the Transport.
+// If too many Acceptors reject the request for good to constitute a quorum,
+// the rejection will be returned, ErrNoAcceptors if there is no Acceptor, or
+// the error of ctx once it is done.
+func quorumVersions(ctx context.Context, t Transport, acceptorIds []int64, namespace, key string) (int64, int64, error) {
+	if len(acceptorIds) == 0 {
+		return 0, 0, ErrNoAcceptors
+	}
+	quorum := len(acceptorIds)/2 + 1
+
+	var count int
+	var latest, floor int64 = -1, 0
+	var rejections []error
 	for _, aid := range acceptorIds {
-		address := fmt.Sprintf("127.0.0.1:%d", AcceptorBasePort+int(aid))
-		conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		reply, err := t.LatestVersion(ctx, aid, &LatestVersionRequest{Key: key, Namespace: namespace})
 		if err != nil {
-			zap.S().Panicf("failed to connect: %v", err)
+			transportLogger.Error("Proposer: request failed",
+				zap.String("method", "LatestVersion"),
+				zap.String("namespace", namespace),
+				zap.String("key", key),
+				zap.Int64("acceptor", aid),
+				zap.Error(err))
+			if e := rejection(err); e != nil {
+				rejections = append(rejections, e)
+			}
+			continue
 		}
 
-		defer conn.Close()
-		c := NewPaxosKVClient(conn)
+		if reply.VotedVer > latest {
+			latest = reply.VotedVer
+		}
+		if reply.Floor > floor {
+			floor = reply.Floor
+		}
+		count += 1
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+	if len(acceptorIds)-len(rejections) < quorum {
+		return 0, 0, rejections[0]
+	}
+	if count < quorum {
+		return 0, 0, ErrNoEnoughQuorum
+	}
+	return latest, floor, nil
+}
+
+// Commit tells the specified Acceptors that the value of the Proposer has been chosen.
+// It is best-effort and never resent: an Acceptor missing it, or refusing it
+// since it has not voted the value at the ballot, learns the value only when
+// paxos runs on the instance again, e.g. when a Client reads the key.
+func (p *Proposer) Commit(acceptorIds []int64) {
+	p.commit(context.Background(), grpcTransport{}, acceptorIds)
+}
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-		defer cancel()
+func (p *Proposer) commit(ctx context.Context, t Transport, acceptorIds []int64) {
+	p.rpcToAll(ctx, t, acceptorIds, "Commit")
+}
+
+// dialAcceptor connects to the Acceptor with the specified id, over TLS if
+// ClientTLS is set, presenting ClientToken if set.
+func dialAcceptor(aid int64) (*grpc.ClientConn, error) {
+	address := fmt.Sprintf("127.0.0.1:%d", AcceptorBasePort+int(aid))
 
-		var reply *Acceptor
-		switch action {
-		case "Prepare":
-			reply, err = c.Prepare(ctx, p)
-		case "Accept":
-			reply, err = c.Accept(ctx, p)
+	creds := insecure.NewCredentials()
+	if ClientTLS != nil {
+		var err error
+		if creds, err = ClientTLS.ClientCredentials(); err != nil {
+			return nil, err
 		}
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if ClientToken != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials(ClientToken)))
+	}
+	return grpc.Dial(address, opts...)
+}
+
+// rejection returns the error if an Acceptor rejects a request for good, which
+// retrying with a higher ballot would not recover from, or nil otherwise.
+func rejection(err error) error {
+	switch status.Code(err) {
+	case codes.OutOfRange:
+		return ErrCompacted
+	case codes.ResourceExhausted:
+		return ErrQuotaExceeded
+	case codes.Unauthenticated:
+		return ErrUnauthenticated
+	case codes.PermissionDenied:
+		return ErrPermissionDenied
+	case codes.Aborted:
+		return ErrStaleEpoch
+	case codes.InvalidArgument:
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// rpcToAll sends Prepare, Accept or Commit RPC to the specified Acceptors
+// through the Transport.
+// It returns the replies and the errors of the Acceptors rejecting for good.
+// Every RPC is traced as a child span of ctx.
+func (p *Proposer) rpcToAll(ctx context.Context, t Transport, acceptorIds []int64, action string) ([]*Acceptor, []error) {
+	var replies []*Acceptor
+	var rejections []error
+
+	for _, aid := range acceptorIds {
+		start := time.Now()
+		rpcCtx, span := tracer.Start(ctx, action, trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.Int64("paxos.acceptor", aid)))
+		reply, err := t.Call(rpcCtx, aid, action, p)
+		endSpan(span, err)
 		if err != nil {
-			zap.S().Errorf("Proposer: %s failure from Acceptor-%d: %v", action, aid, err)
+			transportLogger.Error("Proposer: request failed", instanceFields(p.Id, p.Bal,
+				zap.String("method", action),
+				zap.Int64("acceptor", aid),
+				zap.Duration("latency", time.Since(start)),
+				zap.Error(err))...)
+			if e := rejection(err); e != nil {
+				rejections = append(rejections, e)
+			}
+		} else if ce := proposerLogger.Check(zap.DebugLevel, "Proposer: reply received"); ce != nil {
+			outcome := "ok"
+			if !p.Bal.GE(reply.LastBal) {
+				outcome = outcomeLowerBallot
+			}
+			ce.Write(instanceFields(p.Id, p.Bal,
+				zap.String("method", action),
+				zap.Int64("acceptor", aid),
+				zap.String("outcome", outcome),
+				zap.Int64("last_ballot", reply.LastBal.GetN()),
+				zap.Duration("latency", time.Since(start)))...)
 		}
-		zap.S().Infof("Proposer: receive %s reply from Acceptor-%d: %v", action, aid, reply)
 
 		if reply != nil {
 			replies = append(replies, reply)
 		}
 	}
 
-	return replies
+	return replies, rejections
 }
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestProposer_NoAcceptors(t *testing.T) {
+	r := require.New(t)
+
+	prop := Proposer{
+		Id:  &PaxosInstanceId{Key: "k", Ver: 0},
+		Bal: &BallotNum{N: 0, ProposerId: 2},
+	}
+	_, err := prop.Propose(context.Background(), nil, &Value{Vi64: 1})
+	r.Equal(ErrNoAcceptors, err)
+	_, _, err = prop.Phase1(nil, 1)
+	r.Equal(ErrNoAcceptors, err)
+	_, err = prop.Phase2(nil, 1)
+	r.Equal(ErrNoAcceptors, err)
+
+	client := NewClient(nil, 2)
+	_, _, err = client.Get("k")
+	r.Equal(ErrNoAcceptors, err)
+	_, err = client.Set("k", &Value{Vi64: 1})
+	r.Equal(ErrNoAcceptors, err)
+}
+
+// preemptingTransport preempts the first rounds of a Proposer with a higher
+// ballot, and records the backoff between them instead of sleeping.
+type preemptingTransport struct {
+	preempt int
+	waits   []time.Duration
+}
+
+func (t *preemptingTransport) Call(_ context.Context, _ int64, method string, req *Proposer) (*Acceptor, error) {
+	if method == "Prepare" && t.preempt > 0 {
+		t.preempt -= 1
+		return &Acceptor{LastBal: &BallotNum{N: req.Bal.N + 1, ProposerId: 9}, VBal: &BallotNum{}}, nil
+	}
+	return &Acceptor{LastBal: req.Bal, VBal: &BallotNum{}}, nil
+}
+
+func (t *preemptingTransport) LatestVersion(context.Context, int64, *LatestVersionRequest) (*LatestVersionReply, error) {
+	return &LatestVersionReply{VotedVer: -1, CommittedVer: -1}, nil
+}
+
+func (t *preemptingTransport) Scan(context.Context, int64, *ScanRequest) (*ScanReply, error) {
+	return &ScanReply{}, nil
+}
+
+func (t *preemptingTransport) Fence(context.Context, int64, *FenceRequest) (*FenceReply, error) {
+	return &FenceReply{}, nil
+}
+
+func (t *preemptingTransport) Sleep(d time.Duration) {
+	t.waits = append(t.waits, d)
+}
+
+func TestProposer_Backoff(t *testing.T) {
+	r := require.New(t)
+
+	// the single Acceptor preempts the first 8 rounds
+	transport := &preemptingTransport{preempt: 8}
+	prop := Proposer{
+		Id:  &PaxosInstanceId{Key: "k", Ver: 0},
+		Bal: &BallotNum{N: 0, ProposerId: 2},
+	}
+	val, err := prop.ProposeWith(context.Background(), transport, []int64{0}, &Value{Vi64: 1})
+	r.Nil(err)
+	r.Equal(int64(1), val.Vi64)
+
+	// the delays are random within a bound doubling up to ProposeMaxBackoff
+	r.Len(transport.waits, 8)
+	distinct := map[time.Duration]bool{}
+	for i, d := range transport.waits {
+		bound := ProposeBackoff << i
+		if bound > ProposeMaxBackoff {
+			bound = ProposeMaxBackoff
+		}
+		r.GreaterOrEqual(d, time.Duration(0))
+		r.LessOrEqual(d, bound)
+		distinct[d] = true
+	}
+	r.Greater(len(distinct), 1)
+}
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// Scan handles Scan request.
+//
+// The page is consistent at the read point: it contains the latest version of
+// every key in range which was committed at or before the read point, unless
+// the version is a tombstone and tombstones are not requested. If voted
+// versions are requested, a key without a version committed at the read point
+// is returned with its latest voted version. Transaction records are left out
+// unless the start key is in TxnRecordPrefix.
+func (s *KVServer) Scan(c context.Context, r *ScanRequest) (_ *ScanReply, err error) {
+	startTime := time.Now()
+	defer func() { s.logKeyRequest("Scan", r.Namespace, r.StartKey, startTime, err) }()
+
+	if err = validateKey(r.Namespace, ""); err != nil {
+		return nil, err
+	}
+
+	startKey, readPoint, after := r.StartKey, r.ReadPoint, false
+	if r.PageToken != "" {
+		readPoint, startKey, err = decodePageToken(r.PageToken)
+		if err != nil {
+			return nil, err
+		}
+		after = true
+	}
+	if readPoint <= 0 {
+		readPoint = s.commitLogLength()
+	}
+
+	start, end := storageKey(r.Namespace, startKey), storageKey(r.Namespace, r.EndKey)
+	if r.EndKey == "" && r.Namespace != "" {
+		end = prefixEnd(r.Namespace + namespaceSep)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reply := &ScanReply{ReadPoint: readPoint}
+	for i := sort.SearchStrings(s.keys, start); i < len(s.keys); i++ {
+		key := s.keys[i]
+		if after && key == start {
+			continue
+		}
+		if end != "" && key >= end {
+			break
+		}
+		namespace, k := splitStorageKey(key)
+		if namespace != r.Namespace || hiddenTxnRecord(k, startKey) {
+			continue
+		}
+
+		inst := s.Storage[key].latestCommitted(key, readPoint)
+		if inst == nil && r.Voted {
+			inst = s.Storage[key].latestVoted(key)
+		}
+		if inst == nil || inst.Val.Tombstone && !r.Tombstones {
+			continue
+		}
+
+		if r.Limit > 0 && int64(len(reply.Instances)) == r.Limit {
+			last := reply.Instances[len(reply.Instances)-1].Id.Key
+			reply.NextPageToken = encodePageToken(readPoint, last)
+			break
+		}
+		reply.Instances = append(reply.Instances, inst)
+	}
+
+	return reply, nil
+}
+
+// hiddenTxnRecord reports whether the key is a transaction record hidden from
+// requests on keys not in TxnRecordPrefix.
+func hiddenTxnRecord(key, requested string) bool {
+	return strings.HasPrefix(key, TxnRecordPrefix) && !strings.HasPrefix(requested, TxnRecordPrefix)
+}
+
+// indexKey inserts a new key into the ordered index, s.mu must be held.
+func (s *KVServer) indexKey(key string) {
+	i := sort.SearchStrings(s.keys, key)
+	s.keys = append(s.keys, "")
+	copy(s.keys[i+1:], s.keys[i:])
+	s.keys[i] = key
+}
+
+func (s *KVServer) commitLogLength() int64 {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+
+	return s.lastIndex
+}
+
+// latestCommitted returns the highest version committed at or before the read point.
+func (vs Versions) latestCommitted(skey string, readPoint int64) *Instance {
+	namespace, key := splitStorageKey(skey)
+
+	var latest *Instance
+	for ver, v := range vs {
+		v.mu.Lock()
+		if v.chosen != nil && v.index <= readPoint && (latest == nil || ver > latest.Id.Ver) {
+			latest = &Instance{
+				Id:    &PaxosInstanceId{Key: key, Ver: ver, Namespace: namespace},
+				Val:   v.chosen,
+				Index: v.index,
+			}
+		}
+		v.mu.Unlock()
+	}
+	return latest
+}
+
+// latestVoted returns the highest version voted, with Index 0.
+func (vs Versions) latestVoted(skey string) *Instance {
+	namespace, key := splitStorageKey(skey)
+
+	var latest *Instance
+	for ver, v := range vs {
+		v.mu.Lock()
+		if v.acceptor.Val != nil && (latest == nil || ver > latest.Id.Ver) {
+			latest = &Instance{
+				Id:  &PaxosInstanceId{Key: key, Ver: ver, Namespace: namespace},
+				Val: v.acceptor.Val,
+			}
+		}
+		v.mu.Unlock()
+	}
+	return latest
+}
+
+func encodePageToken(readPoint int64, lastKey string) string {
+	return base64.URLEncoding.EncodeToString([]byte(fmt.Sprintf("%d/%s", readPoint, lastKey)))
+}
+
+func decodePageToken(token string) (int64, string, error) {
+	b, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, "", ErrInvalidPageToken
+	}
+
+	parts := strings.SplitN(string(b), "/", 2)
+	if len(parts) != 2 {
+		return 0, "", ErrInvalidPageToken
+	}
+	readPoint, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidPageToken
+	}
+	return readPoint, parts[1], nil
+}
+
+// Scan returns a page of the latest committed versions of the keys in range
+// [startKey, endKey) of the namespace of the Client from an Acceptor. Pass the
+// NextPageToken of a page as pageToken to get the next page at the same read
+// point. Expired values are returned as is, see Value.Expired.
+//
+// Intents of transactions are resolved, see Txn: the intent of a transaction
+// not committed is replaced by the value in effect read from a quorum, at the
+// version it is observed at, see Get. A key without a value in effect is left
+// out, so a page may hold fewer instances than the limit.
+func (c *Client) Scan(aid int64, startKey, endKey string, limit int64, pageToken string) (*ScanReply, error) {
+	return c.scan(aid, &ScanRequest{
+		StartKey:  startKey,
+		EndKey:    endKey,
+		Limit:     limit,
+		PageToken: pageToken,
+	})
+}
+
+// ListPrefix returns a page of the latest committed versions of the keys with
+// the prefix from an Acceptor, see Scan.
+func (c *Client) ListPrefix(aid int64, prefix string, limit int64, pageToken string) (*ScanReply, error) {
+	return c.Scan(aid, prefix, prefixEnd(prefix), limit, pageToken)
+}
+
+// scan runs a Scan request on an Acceptor in the namespace of the Client, and
+// resolves the intents of transactions in the page.
+func (c *Client) scan(aid int64, req *ScanRequest) (*ScanReply, error) {
+	req.Namespace = c.Namespace
+	reply, err := c.transport().Scan(context.Background(), aid, req)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := reply.Instances[:0]
+	for _, inst := range reply.Instances {
+		committed := true
+		if inst.Val.TxnId != "" {
+			if committed, err = c.txnCommitted(context.Background(), inst.Val.TxnId); err != nil {
+				return nil, err
+			}
+		}
+		if !committed {
+			_, floor, err := quorumVersions(context.Background(), c.transport(), c.AcceptorIds, c.Namespace, inst.Id.Key)
+			if err != nil {
+				return nil, err
+			}
+			val, _, err := c.resolve(context.Background(), inst.Id.Key, inst.Id.Ver-1, floor)
+			if err != nil {
+				return nil, err
+			}
+			if val == nil || val.Tombstone && !req.Tombstones {
+				continue
+			}
+			inst = &Instance{Id: inst.Id, Val: val}
+		}
+		instances = append(instances, inst)
+	}
+	reply.Instances = instances
+	return reply, nil
+}
+
+// ScanAcceptor runs a Scan request on an Acceptor.
+func ScanAcceptor(aid int64, req *ScanRequest) (*ScanReply, error) {
+	return grpcTransport{}.Scan(context.Background(), aid, req)
+}
+
+// prefixEnd returns the smallest key greater than all the keys with the prefix,
+// or an empty string if there is no such key.
+func prefixEnd(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
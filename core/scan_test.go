@@ -0,0 +1,87 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func commit(s *KVServer, key string, ver int64, val int64) {
+	p := &Proposer{
+		Id:  &PaxosInstanceId{Key: key, Ver: ver},
+		Bal: &BallotNum{N: 1},
+		Val: &Value{Vi64: val},
+	}
+	_, _ = s.Accept(nil, p)
+	_, _ = s.Commit(nil, p)
+}
+
+func scanKeys(reply *ScanReply) []string {
+	var keys []string
+	for _, inst := range reply.Instances {
+		keys = append(keys, inst.Id.Key)
+	}
+	return keys
+}
+
+func TestAcceptor_ScanPagination(t *testing.T) {
+	r := require.New(t)
+
+	s := &KVServer{Storage: map[string]Versions{}}
+	for _, key := range []string{"d", "b", "a", "c", "e"} {
+		commit(s, key, 0, 1)
+	}
+	// only voted, not committed
+	_, _ = s.Accept(nil, &Proposer{
+		Id:  &PaxosInstanceId{Key: "bb", Ver: 0},
+		Bal: &BallotNum{N: 1},
+		Val: &Value{Vi64: 1},
+	})
+
+	reply, err := s.Scan(nil, &ScanRequest{StartKey: "b", EndKey: "e", Limit: 2})
+	r.Nil(err)
+	r.Equal([]string{"b", "c"}, scanKeys(reply))
+	r.NotEmpty(reply.NextPageToken)
+
+	// a commit after the read point is invisible to the next page
+	commit(s, "d", 1, 2)
+	commit(s, "cc", 0, 1)
+
+	reply, err = s.Scan(nil, &ScanRequest{EndKey: "e", Limit: 2, PageToken: reply.NextPageToken})
+	r.Nil(err)
+	r.Equal([]string{"d"}, scanKeys(reply))
+	r.Equal(int64(0), reply.Instances[0].Id.Ver)
+	r.Empty(reply.NextPageToken)
+
+	reply, err = s.Scan(nil, &ScanRequest{StartKey: "c", EndKey: "e"})
+	r.Nil(err)
+	r.Equal([]string{"c", "cc", "d"}, scanKeys(reply))
+	r.Equal(int64(1), reply.Instances[2].Id.Ver)
+
+	_, err = s.Scan(nil, &ScanRequest{PageToken: "?"})
+	r.Equal(ErrInvalidPageToken, err)
+}
+
+func TestListPrefix(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	set(acceptorIds, "user/a", 0, 1)
+	set(acceptorIds, "user/b", 0, 2)
+	set(acceptorIds, "user/b", 1, 3)
+	set(acceptorIds, "userx", 0, 4)
+
+	reply, err := NewClient(acceptorIds, 1).ListPrefix(0, "user/", 0, "")
+	r.Nil(err)
+	r.Equal([]string{"user/a", "user/b"}, scanKeys(reply))
+	r.Equal(int64(3), reply.Instances[1].Val.Vi64)
+
+	r.Equal("user0", prefixEnd("user/"))
+	r.Equal("", prefixEnd("\xff"))
+}
@@ -21,11 +21,7 @@ func TestCase1SingleProposer(t *testing.T) {
 	quorum := 2
 
 	servers := ServeAcceptors(acceptorIds)
-	defer func() {
-		for _, s := range servers {
-			s.Stop()
-		}
-	}()
+	defer servers.Stop()
 
 	// The proposer try to set i₀ = 10
 	var val int64 = 10
@@ -69,11 +65,7 @@ func TestCase2DoubleProposer(t *testing.T) {
 	quorum := 2
 
 	servers := ServeAcceptors(acceptorIds)
-	defer func() {
-		for _, s := range servers {
-			s.Stop()
-		}
-	}()
+	defer servers.Stop()
 
 	// two proposer
 	var pidx int64 = 10
@@ -11,11 +11,7 @@ func TestAcceptor_SetAndGetByKey(t *testing.T) {
 	// start up 3 acceptors
 	acceptorIds := []int64{0, 1, 2}
 	servers := ServeAcceptors(acceptorIds)
-	defer func() {
-		for _, server := range servers {
-			server.Stop()
-		}
-	}()
+	defer servers.Stop()
 
 	// set k-0 = 5
 	{
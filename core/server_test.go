@@ -4,6 +4,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/khighness/highness-paxos-kv/pkg/logging"
 )
 
 // @Author KHighness
@@ -29,3 +33,93 @@ func TestAcceptor_Accept_LastBal(t *testing.T) {
 	version.acceptor.LastBal.N = 100
 	r.Equal(int64(0), reply.LastBal.N)
 }
+
+func TestAcceptor_LatestVersion(t *testing.T) {
+	r := require.New(t)
+
+	kvServer := KVServer{Storage: map[string]Versions{}}
+	propose := func(ver int64) *Proposer {
+		return &Proposer{
+			Id:  &PaxosInstanceId{Key: "k", Ver: ver},
+			Bal: &BallotNum{N: 1},
+			Val: &Value{Vi64: ver},
+		}
+	}
+
+	reply, err := kvServer.LatestVersion(nil, &LatestVersionRequest{Key: "k"})
+	r.Nil(err)
+	r.Equal(int64(-1), reply.VotedVer)
+	r.Equal(int64(-1), reply.CommittedVer)
+
+	_, err = kvServer.Accept(nil, propose(0))
+	r.Nil(err)
+	_, err = kvServer.Commit(nil, propose(0))
+	r.Nil(err)
+	_, err = kvServer.Accept(nil, propose(1))
+	r.Nil(err)
+	_, err = kvServer.Prepare(nil, propose(2))
+	r.Nil(err)
+
+	reply, err = kvServer.LatestVersion(nil, &LatestVersionRequest{Key: "k"})
+	r.Nil(err)
+	r.Equal(int64(1), reply.VotedVer)
+	r.Equal(int64(0), reply.CommittedVer)
+}
+
+func TestAcceptor_Commit(t *testing.T) {
+	r := require.New(t)
+
+	kvServer := KVServer{Storage: map[string]Versions{}}
+	propose := func(n, val int64) *Proposer {
+		return &Proposer{
+			Id:  &PaxosInstanceId{Key: "k", Ver: 0},
+			Bal: &BallotNum{N: n},
+			Val: &Value{Vi64: val},
+		}
+	}
+
+	// a value never voted is refused, and creates no version
+	_, err := kvServer.Commit(nil, propose(1, 1))
+	r.Equal(ErrNotVoted, err)
+	r.NotContains(kvServer.Storage, "k")
+
+	_, err = kvServer.Accept(nil, propose(1, 1))
+	r.Nil(err)
+
+	// the value and the ballot must match the vote
+	_, err = kvServer.Commit(nil, propose(1, 2))
+	r.Equal(ErrNotVoted, err)
+	_, err = kvServer.Commit(nil, propose(2, 1))
+	r.Equal(ErrNotVoted, err)
+	r.Empty(kvServer.commitLog)
+
+	_, err = kvServer.Commit(nil, propose(1, 1))
+	r.Nil(err)
+	r.Len(kvServer.commitLog, 1)
+
+	// the chosen value is committed once, another one is refused
+	_, err = kvServer.Commit(nil, propose(1, 1))
+	r.Nil(err)
+	r.Len(kvServer.commitLog, 1)
+	_, err = kvServer.Accept(nil, propose(3, 2))
+	r.Nil(err)
+	_, err = kvServer.Commit(nil, propose(3, 2))
+	r.Equal(ErrNotVoted, err)
+	r.Len(kvServer.commitLog, 1)
+}
+
+func TestAcceptor_LogLevel(t *testing.T) {
+	r := require.New(t)
+
+	servers := ServeAcceptors([]int64{0})
+	defer servers.Stop()
+	defer func() { _ = logging.SetLevel(logging.Transport, "info") }()
+
+	levels, err := SetLogLevel(0, logging.Transport, "debug")
+	r.Nil(err)
+	r.Equal("debug", levels[logging.Transport])
+	r.Equal("info", levels[logging.Acceptor])
+
+	_, err = SetLogLevel(0, "storage", "debug")
+	r.Equal(codes.InvalidArgument, status.Code(err))
+}
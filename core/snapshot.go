@@ -0,0 +1,64 @@
+package core
+
+import (
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// InstanceState is the state of an Acceptor on a paxos instance.
+type InstanceState struct {
+	Id *PaxosInstanceId
+	// Acceptor is the promised ballot and the voted value and ballot.
+	Acceptor *Acceptor
+	// Chosen is the committed value, nil if the Acceptor has not learned it.
+	Chosen *Value
+}
+
+// Snapshot returns a copy of the states of the Acceptor on all the instances
+// it stores, ordered by namespace, key and version.
+func (s *KVServer) Snapshot() []*InstanceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var states []*InstanceState
+	for key, versions := range s.Storage {
+		namespace, k := splitStorageKey(key)
+		for ver, v := range versions {
+			v.mu.Lock()
+			state := &InstanceState{
+				Id:       &PaxosInstanceId{Namespace: namespace, Key: k, Ver: ver},
+				Acceptor: proto.Clone(&v.acceptor).(*Acceptor),
+			}
+			if v.chosen != nil {
+				state.Chosen = proto.Clone(v.chosen).(*Value)
+			}
+			v.mu.Unlock()
+			states = append(states, state)
+		}
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		a, b := states[i].Id, states[j].Id
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Ver < b.Ver
+	})
+	return states
+}
+
+// KVServers returns the Acceptors served, by id, e.g. to inspect their storage.
+func (a *Acceptors) KVServers() map[int64]*KVServer {
+	kvs := map[int64]*KVServer{}
+	for _, as := range a.servers {
+		kvs[as.Id] = as.kv
+	}
+	return kvs
+}
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+var (
+	ErrInvalidCA     = errors.New("no certificate found in CA file")
+	ErrNoCertificate = errors.New("no certificate for the server")
+)
+
+// ClientTLS is the TLS configuration Proposers, Learners and watchers dial
+// Acceptors with, nil for plaintext.
+var ClientTLS *TLSConfig
+
+// TLSConfig is the TLS configuration of an Acceptor or of its callers, loaded
+// from PEM files.
+//
+// The files are checked for modification on every handshake and reloaded if
+// changed, so certificates can be rotated without restart. If reloading fails,
+// e.g. the files are being rewritten, the previously loaded ones are kept, and
+// the files are not reloaded again until they are modified again.
+type TLSConfig struct {
+	// CertFile and KeyFile are the certificate and private key presented to
+	// the peer. For a caller, they are optional unless Acceptors require
+	// client certificates.
+	CertFile string
+	KeyFile  string
+	// CAFile is the CA certificates verifying the peer. For an Acceptor, it
+	// verifies client certificates; for a caller, the system pool is used if
+	// it is empty.
+	CAFile string
+	// ClientAuth makes an Acceptor require and verify client certificates,
+	// i.e. mutual TLS.
+	ClientAuth bool
+	// ServerName is the name a caller verifies the certificate of Acceptors
+	// against, the host dialed by default.
+	ServerName string
+
+	mu       sync.Mutex
+	modTime  time.Time
+	cert     *tls.Certificate
+	certPool *x509.CertPool
+	// failedModTime is the modification time of the files failed to reload,
+	// they are not reloaded again until modified. failure is the error of the
+	// last failed reload, a failure is logged only once.
+	failedModTime time.Time
+	failure       string
+}
+
+// WithTLS serves the acceptors over TLS.
+func WithTLS(c *TLSConfig) ServerOption {
+	return func(s *KVServer) {
+		s.tls = c
+	}
+}
+
+// ServerCredentials returns the gRPC credentials of an Acceptor.
+func (c *TLSConfig) ServerCredentials() (credentials.TransportCredentials, error) {
+	loaded, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	if loaded.cert == nil {
+		return nil, ErrNoCertificate
+	}
+
+	return credentials.NewTLS(&tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			loaded, err := c.load()
+			if err != nil {
+				return nil, err
+			}
+
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*loaded.cert},
+			}
+			if c.ClientAuth {
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+				config.ClientCAs = loaded.certPool
+			}
+			return config, nil
+		},
+	}), nil
+}
+
+// ClientCredentials returns the gRPC credentials of a caller with the files
+// currently loaded.
+func (c *TLSConfig) ClientCredentials() (credentials.TransportCredentials, error) {
+	loaded, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    loaded.certPool,
+		ServerName: c.ServerName,
+	}
+	if loaded.cert != nil {
+		config.Certificates = []tls.Certificate{*loaded.cert}
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// loaded is a snapshot of the files loaded.
+type loaded struct {
+	cert     *tls.Certificate
+	certPool *x509.CertPool
+}
+
+// load returns the loaded files, reloading them if any has been modified
+// since last load.
+func (c *TLSConfig) load() (loaded, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	modTime, err := c.latestModTime()
+	if err == nil && (c.modTime.Equal(modTime) || c.failedModTime.Equal(modTime)) {
+		return loaded{c.cert, c.certPool}, nil
+	}
+	if err == nil {
+		err = c.reloadLocked()
+	}
+
+	if err != nil {
+		if c.modTime.IsZero() {
+			return loaded{}, err
+		}
+		c.failedModTime = modTime
+		if c.failure != err.Error() {
+			c.failure = err.Error()
+			transportLog.Errorf("TLS: failed to reload, keep the loaded files: %v", err)
+		}
+		return loaded{c.cert, c.certPool}, nil
+	}
+
+	c.modTime, c.failedModTime, c.failure = modTime, time.Time{}, ""
+	transportLog.Infof("TLS: loaded certificate %s and CA %s", c.CertFile, c.CAFile)
+	return loaded{c.cert, c.certPool}, nil
+}
+
+func (c *TLSConfig) reloadLocked() error {
+	var cert *tls.Certificate
+	if c.CertFile != "" || c.KeyFile != "" {
+		pair, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return err
+		}
+		cert = &pair
+	}
+
+	var certPool *x509.CertPool
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return err
+		}
+		certPool = x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(pem) {
+			return ErrInvalidCA
+		}
+	}
+
+	c.cert, c.certPool = cert, certPool
+	return nil
+}
+
+// latestModTime returns the latest modification time of the files.
+func (c *TLSConfig) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{c.CertFile, c.KeyFile, c.CAFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
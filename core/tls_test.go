@@ -0,0 +1,179 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+// testCA issues certificates for tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(r *require.Assertions, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	r.Nil(err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	r.Nil(err)
+	cert, err := x509.ParseCertificate(der)
+	r.Nil(err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue writes a certificate for 127.0.0.1 and its key to the files.
+func (ca *testCA) issue(r *require.Assertions, name, certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	r.Nil(err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	r.Nil(err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	r.Nil(err)
+
+	r.Nil(os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	r.Nil(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+}
+
+func TestTLS_MutualAuthAndReload(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+	file := func(name string) string { return filepath.Join(dir, name) }
+
+	ca := newTestCA(r, "ca")
+	r.Nil(os.WriteFile(file("ca.pem"), ca.pem, 0600))
+	ca.issue(r, "acceptor", file("server.pem"), file("server.key"))
+	ca.issue(r, "proposer", file("client.pem"), file("client.key"))
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds, WithTLS(&TLSConfig{
+		CertFile:   file("server.pem"),
+		KeyFile:    file("server.key"),
+		CAFile:     file("ca.pem"),
+		ClientAuth: true,
+	}))
+	defer func() {
+		ClientTLS = nil
+		servers.Stop()
+	}()
+
+	client := NewClient(acceptorIds, 1)
+
+	// plaintext and unauthenticated callers are refused
+	_, err := client.Set("k", &Value{Vi64: 1})
+	r.Equal(ErrNoEnoughQuorum, err)
+	ClientTLS = &TLSConfig{CAFile: file("ca.pem")}
+	_, err = client.Set("k", &Value{Vi64: 1})
+	r.Equal(ErrNoEnoughQuorum, err)
+
+	ClientTLS = &TLSConfig{CertFile: file("client.pem"), KeyFile: file("client.key"), CAFile: file("ca.pem")}
+	_, err = client.Set("k", &Value{Vi64: 1})
+	r.Nil(err)
+
+	// rotate every certificate to a new CA without restarting the acceptors
+	rotated := newTestCA(r, "rotated")
+	r.Nil(os.WriteFile(file("ca.pem"), rotated.pem, 0600))
+	rotated.issue(r, "acceptor", file("server.pem"), file("server.key"))
+	rotated.issue(r, "proposer", file("client.pem"), file("client.key"))
+
+	// make sure the modification is visible on file systems of coarse mtime
+	later := time.Now().Add(time.Minute)
+	for _, name := range []string{"ca.pem", "server.pem", "server.key", "client.pem", "client.key"} {
+		r.Nil(os.Chtimes(file(name), later, later))
+	}
+
+	val, _, err := client.Get("k")
+	r.Nil(err)
+	r.Equal(int64(1), val.Vi64)
+}
+
+func TestTLS_FailedReload(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+	file := func(name string) string { return filepath.Join(dir, name) }
+	touch := func(at time.Time) {
+		r.Nil(os.Chtimes(file("server.pem"), at, at))
+		r.Nil(os.Chtimes(file("server.key"), at, at))
+	}
+
+	ca := newTestCA(r, "ca")
+	ca.issue(r, "acceptor", file("server.pem"), file("server.key"))
+	c := &TLSConfig{CertFile: file("server.pem"), KeyFile: file("server.key")}
+	first, err := c.load()
+	r.Nil(err)
+
+	// a broken rewrite keeps the loaded files
+	r.Nil(os.WriteFile(file("server.pem"), []byte("broken"), 0600))
+	broken := time.Now().Add(time.Minute)
+	touch(broken)
+	loaded, err := c.load()
+	r.Nil(err)
+	r.Same(first.cert, loaded.cert)
+	r.True(broken.Equal(c.failedModTime))
+
+	// the broken files are not reloaded on every handshake
+	ca.issue(r, "acceptor", file("server.pem"), file("server.key"))
+	touch(broken)
+	loaded, err = c.load()
+	r.Nil(err)
+	r.Same(first.cert, loaded.cert)
+
+	// until they are modified again
+	touch(broken.Add(time.Minute))
+	loaded, err = c.load()
+	r.Nil(err)
+	r.NotSame(first.cert, loaded.cert)
+	r.True(c.failedModTime.IsZero())
+}
+
+// withTestTLS issues the certificates of the acceptors into a temporary
+// directory, makes the callers trust them until the test ends, and returns
+// the option serving the acceptors over TLS, e.g. to present bearer tokens.
+func withTestTLS(t *testing.T, r *require.Assertions) ServerOption {
+	dir := t.TempDir()
+	file := func(name string) string { return filepath.Join(dir, name) }
+
+	ca := newTestCA(r, "ca")
+	r.Nil(os.WriteFile(file("ca.pem"), ca.pem, 0600))
+	ca.issue(r, "acceptor", file("server.pem"), file("server.key"))
+
+	ClientTLS = &TLSConfig{CAFile: file("ca.pem")}
+	t.Cleanup(func() { ClientTLS = nil })
+	return WithTLS(&TLSConfig{CertFile: file("server.pem"), KeyFile: file("server.key")})
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// tracer creates spans with the global TracerProvider, which drops them
+// unless one is installed, e.g. by tracing.Setup.
+var tracer = otel.Tracer("github.com/khighness/highness-paxos-kv/core")
+
+// propagator propagates trace context from Proposers to Acceptors in gRPC
+// metadata, regardless of the global propagator.
+var propagator = propagation.TraceContext{}
+
+// instanceAttributes returns the span attributes of a paxos instance.
+func instanceAttributes(p *Proposer) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("paxos.namespace", p.Id.GetNamespace()),
+		attribute.String("paxos.key", p.Id.GetKey()),
+		attribute.Int64("paxos.ver", p.Id.GetVer()),
+		attribute.Int64("paxos.ballot", p.Bal.GetN()),
+		attribute.Int64("paxos.proposer", p.Bal.GetProposerId()),
+	}
+}
+
+// endSpan records the error if any and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
+
+// injectTrace propagates the span of ctx in the outgoing gRPC metadata.
+func injectTrace(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	propagator.Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// traceUnaryInterceptor starts a span for every unary request, as a child of
+// the span propagated in the incoming gRPC metadata.
+func traceUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = propagator.Extract(ctx, metadataCarrier(md))
+	}
+
+	name := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+	ctx, span := tracer.Start(ctx, "KVServer."+name, trace.WithSpanKind(trace.SpanKindServer))
+	if p, ok := req.(*Proposer); ok {
+		span.SetAttributes(instanceAttributes(p)...)
+	}
+
+	reply, err := handler(ctx, req)
+	endSpan(span, err)
+	return reply, err
+}
+
+// metadataCarrier adapts gRPC metadata to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestTrace_RunPaxos(t *testing.T) {
+	r := require.New(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	p := Proposer{Id: &PaxosInstanceId{Key: "k", Ver: 0}, Bal: &BallotNum{ProposerId: 1}}
+	_, err := p.Propose(context.Background(), acceptorIds, &Value{Vi64: 1})
+	r.Nil(err)
+
+	spans := map[string][]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+	r.Len(spans["RunPaxos"], 1)
+	r.Len(spans["Phase1"], 1)
+	r.Len(spans["Phase2"], 1)
+	r.Len(spans["Prepare"], 3)
+	r.Len(spans["KVServer.Prepare"], 3)
+	r.Len(spans["KVServer.Commit"], 3)
+
+	root := spans["RunPaxos"][0].SpanContext()
+	r.Equal(root.SpanID(), spans["Phase1"][0].Parent().SpanID())
+	r.Equal(spans["Phase1"][0].SpanContext().SpanID(), spans["Prepare"][0].Parent().SpanID())
+
+	// the trace context is propagated to Acceptors
+	clientSpans := map[trace.SpanID]bool{}
+	for _, span := range spans["Prepare"] {
+		clientSpans[span.SpanContext().SpanID()] = true
+	}
+	for _, span := range spans["KVServer.Prepare"] {
+		r.Equal(root.TraceID(), span.SpanContext().TraceID())
+		r.True(clientSpans[span.Parent().SpanID()])
+	}
+}
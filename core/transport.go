@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// Transport carries the requests of Proposers and Clients to Acceptors.
+type Transport interface {
+	// Call sends the request of the method, Prepare, Accept or Commit, to the
+	// Acceptor, and returns its reply.
+	Call(ctx context.Context, aid int64, method string, req *Proposer) (*Acceptor, error)
+	// LatestVersion sends a LatestVersion request to the Acceptor.
+	LatestVersion(ctx context.Context, aid int64, req *LatestVersionRequest) (*LatestVersionReply, error)
+	// Scan sends a Scan request to the Acceptor.
+	Scan(ctx context.Context, aid int64, req *ScanRequest) (*ScanReply, error)
+	// Fence sends a Fence request to the Acceptor.
+	Fence(ctx context.Context, aid int64, req *FenceRequest) (*FenceReply, error)
+}
+
+// OrGRPC returns the Transport, or gRPC if it is nil.
+func OrGRPC(t Transport) Transport {
+	if t == nil {
+		return grpcTransport{}
+	}
+	return t
+}
+
+// grpcTransport sends requests to the Acceptors served by ServeAcceptors.
+type grpcTransport struct{}
+
+func (grpcTransport) Call(ctx context.Context, aid int64, method string, req *Proposer) (*Acceptor, error) {
+	conn, err := dialAcceptor(aid)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	c := NewPaxosKVClient(conn)
+
+	ctx, cancel := context.WithTimeout(injectTrace(ctx), time.Second)
+	defer cancel()
+
+	switch method {
+	case "Prepare":
+		return c.Prepare(ctx, req)
+	case "Accept":
+		return c.Accept(ctx, req)
+	default:
+		return c.Commit(ctx, req)
+	}
+}
+
+func (grpcTransport) LatestVersion(ctx context.Context, aid int64, req *LatestVersionRequest) (*LatestVersionReply, error) {
+	conn, err := dialAcceptor(aid)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	return NewPaxosKVClient(conn).LatestVersion(ctx, req)
+}
+
+func (grpcTransport) Scan(ctx context.Context, aid int64, req *ScanRequest) (*ScanReply, error) {
+	conn, err := dialAcceptor(aid)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	return NewPaxosKVClient(conn).Scan(ctx, req)
+}
+
+func (grpcTransport) Fence(ctx context.Context, aid int64, req *FenceRequest) (*FenceReply, error) {
+	conn, err := dialAcceptor(aid)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	return NewPaxosKVClient(conn).Fence(ctx, req)
+}
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// Expired reports whether the value has expired at the specified time.
+func (v *Value) Expired(now time.Time) bool {
+	return v.ExpireAt != 0 && now.UnixNano() >= v.ExpireAt
+}
+
+// SetWithTTL writes the value as the next version of the key, which expires
+// after ttl according to the clock of the Client, and returns the version.
+func (c *Client) SetWithTTL(key string, val *Value, ttl time.Duration) (int64, error) {
+	val = proto.Clone(val).(*Value)
+	val.ExpireAt = c.now().Add(ttl).UnixNano()
+	return c.Set(key, val)
+}
+
+// Sweeper deletes expired keys in background, according to the clock of its
+// Client, and the records of finished transactions, see SweepTxns.
+//
+// Expiration never relies on the clocks of Acceptors: a Sweeper proposes a
+// tombstone on the version following the expired one, which is chosen only
+// if no other value has been written after the expired version.
+type Sweeper struct {
+	client   *Client
+	interval time.Duration
+	// grace is the extra time to wait after expiration, tolerating clock skew
+	// between the Sweeper and the writers.
+	grace time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSweeper creates a Sweeper deleting expired keys with the Client every interval.
+func NewSweeper(client *Client, interval, grace time.Duration) *Sweeper {
+	return &Sweeper{client: client, interval: interval, grace: grace}
+}
+
+// Start sweeps expired keys every interval in background.
+func (s *Sweeper) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Sweep()
+				s.SweepTxns()
+			}
+		}
+	}()
+}
+
+// Stop stops sweeping and waits for the background goroutine to exit.
+func (s *Sweeper) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Sweep runs one round of sweeping, it returns the number of deleted keys.
+func (s *Sweeper) Sweep() int {
+	now := s.client.now().Add(-s.grace)
+
+	// Commits are best-effort, an Acceptor may miss some versions: the latest
+	// version of a key any Acceptor knows of is the one swept, tombstones
+	// included, so that a key already deleted is not deleted again.
+	latest := map[string]*Instance{}
+	for _, aid := range s.client.AcceptorIds {
+		req := &ScanRequest{Tombstones: true}
+		for {
+			reply, err := s.client.scan(aid, req)
+			if err != nil {
+				proposerLog.Errorf("Sweeper: failed to scan Acceptor-%d: %v", aid, err)
+				break
+			}
+			for _, inst := range reply.Instances {
+				if prev, ok := latest[inst.Id.Key]; !ok || inst.Id.Ver > prev.Id.Ver {
+					latest[inst.Id.Key] = inst
+				}
+			}
+			if req.PageToken = reply.NextPageToken; req.PageToken == "" {
+				break
+			}
+		}
+	}
+
+	var deleted int
+	for key, inst := range latest {
+		if inst.Val.Tombstone || !inst.Val.Expired(now) {
+			continue
+		}
+		ver := inst.Id.Ver
+		if s.client.CompareAndSet(key, ver+1, &Value{Tombstone: true}) {
+			proposerLog.Infof("Sweeper: expired key %s deleted at version %d", key, ver+1)
+			deleted += 1
+		}
+	}
+	return deleted
+}
+
+// SweepTxns deletes the records of finished transactions, it returns the
+// number of deleted records.
+//
+// A record is deleted by a tombstone on its version 1, then reclaimed by the
+// garbage collection of the Acceptors, see CollectGarbage. The record of an
+// aborted transaction is deleted at once, since a reader finding it reclaimed
+// takes the transaction as aborted. The record of a committed transaction is
+// deleted only once every intent of it is reclaimed by a quorum, i.e. it is
+// superseded by a later version in effect, so that no reader resolves them
+// any more.
+func (s *Sweeper) SweepTxns() int {
+	records := map[string]*Value{}
+	for _, aid := range s.client.AcceptorIds {
+		req := &ScanRequest{StartKey: TxnRecordPrefix, EndKey: prefixEnd(TxnRecordPrefix), Namespace: s.client.Namespace}
+		for {
+			reply, err := s.client.transport().Scan(context.Background(), aid, req)
+			if err != nil {
+				proposerLog.Errorf("Sweeper: failed to scan Acceptor-%d: %v", aid, err)
+				break
+			}
+			for _, inst := range reply.Instances {
+				if inst.Id.Ver == 0 {
+					records[inst.Id.Key] = inst.Val
+				}
+			}
+			if req.PageToken = reply.NextPageToken; req.PageToken == "" {
+				break
+			}
+		}
+	}
+
+	var deleted int
+	for key, record := range records {
+		if record.Vi64 == TxnCommitted {
+			reclaimed, err := s.client.intentsReclaimed(record)
+			if err != nil {
+				proposerLog.Errorf("Sweeper: invalid transaction record %s: %v", key, err)
+			}
+			if !reclaimed {
+				continue
+			}
+		}
+		if s.client.CompareAndSet(key, 1, &Value{Tombstone: true}) {
+			proposerLog.Infof("Sweeper: transaction record %s deleted", key)
+			deleted += 1
+		}
+	}
+	return deleted
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestClient_TTLAndSweeper(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	// expiration is decided by the clock of the client, not by sleeping
+	now := time.Now()
+	client := NewClient(acceptorIds, 2)
+	client.Now = func() time.Time { return now }
+
+	_, err := client.SetWithTTL("session", &Value{Vi64: 1}, time.Minute)
+	r.Nil(err)
+	_, err = client.Set("config", &Value{Vi64: 2})
+	r.Nil(err)
+
+	val, _, err := client.Get("session")
+	r.Nil(err)
+	r.Equal(int64(1), val.Vi64)
+
+	now = now.Add(time.Minute)
+
+	_, _, err = client.Get("session")
+	r.Equal(ErrNotFound, err)
+
+	// the grace period is not over yet
+	faults := NewFaults(1)
+	sweeperClient := NewClient(acceptorIds, 3)
+	sweeperClient.Now = client.Now
+	sweeperClient.Transport = faults.Transport(nil)
+	sweeper := NewSweeper(sweeperClient, time.Second, time.Second)
+	r.Equal(0, sweeper.Sweep())
+	now = now.Add(time.Second)
+
+	// Acceptor-2 misses the tombstone, the key is not deleted twice
+	faults.Partition(2)
+	r.Equal(1, sweeper.Sweep())
+	faults.Heal()
+	r.Equal(0, sweeper.Sweep())
+
+	ver, err := LatestVersion(acceptorIds, "session")
+	r.Nil(err)
+	r.Equal(int64(1), ver)
+
+	_, _, err = client.Get("config")
+	r.Nil(err)
+}
@@ -0,0 +1,222 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// TxnRecordPrefix is the key prefix of transaction records.
+const TxnRecordPrefix = "__txn/"
+
+// The status of a transaction, chosen as `Vi64` of version 0 of its record.
+// The record of a committed transaction lists its intents in `Data`, see TxnIntent.
+const (
+	TxnCommitted int64 = 1
+	TxnAborted   int64 = 2
+)
+
+var ErrTxnConflict = errors.New("transaction conflicts with another write")
+
+// TxnPendingTimeout is the time a reader or a watcher gives a pending
+// transaction to be decided before aborting it.
+var TxnPendingTimeout = time.Second
+
+var txnSeq int64
+
+// TxnIntent is an intent written by a transaction, on a version of a key.
+type TxnIntent struct {
+	Key string
+	Ver int64
+}
+
+// Txn is a transaction updating multiple keys atomically.
+//
+// A Txn records the version every key is read at as the read set, and the
+// values to write as the write set. On commit, it writes an intent on the
+// version following the expected version of every key in the read set and the
+// write set with compare-and-set. An intent is a value carrying the id of the
+// transaction, for a key only read, it is a copy of the read value.
+// Then it tries to choose TxnCommitted as the status of its transaction record.
+//
+// An intent takes effect only if the status of the transaction is committed.
+// A reader running into an intent of a pending transaction gives it
+// TxnPendingTimeout to be decided, then aborts the transaction by choosing
+// TxnAborted as its status, so that an abandoned transaction never blocks
+// others for long. Get, Scan and Watch resolve intents, and
+// the records of finished transactions are deleted by a Sweeper, see SweepTxns.
+type Txn struct {
+	client *Client
+	id     string
+
+	// reads stores the expected version and the read value of every key.
+	reads  map[string]txnRead
+	writes map[string]*Value
+}
+
+type txnRead struct {
+	ver int64
+	val *Value
+}
+
+// Begin starts a transaction.
+func (c *Client) Begin() *Txn {
+	return &Txn{
+		client: c,
+		id:     fmt.Sprintf("%d-%d-%d", c.ProposerId, time.Now().UnixNano(), atomic.AddInt64(&txnSeq, 1)),
+		reads:  map[string]txnRead{},
+		writes: map[string]*Value{},
+	}
+}
+
+// Id returns the id of the transaction.
+func (t *Txn) Id() string {
+	return t.id
+}
+
+// Get reads the key in the transaction, it returns ErrNotFound if the key does
+// not exist. The version it is read at becomes the expected version of the key.
+func (t *Txn) Get(key string) (*Value, error) {
+	if val, ok := t.writes[key]; ok {
+		if val.Tombstone {
+			return nil, ErrNotFound
+		}
+		return val, nil
+	}
+	if r, ok := t.reads[key]; ok {
+		if r.val == nil {
+			return nil, ErrNotFound
+		}
+		return r.val, nil
+	}
+
+	val, ver, err := t.client.Get(key)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	t.reads[key] = txnRead{ver: ver, val: val}
+	return val, err
+}
+
+// Set writes the value to the key in the transaction.
+func (t *Txn) Set(key string, val *Value) error {
+	if _, err := t.Get(key); err != nil && err != ErrNotFound {
+		return err
+	}
+	t.writes[key] = val
+	return nil
+}
+
+// Delete deletes the key in the transaction.
+func (t *Txn) Delete(key string) error {
+	return t.Set(key, &Value{Tombstone: true})
+}
+
+// Commit commits the transaction atomically.
+// It returns ErrTxnConflict if any key has been changed since it is read, or
+// the transaction has been aborted by others.
+func (t *Txn) Commit() error {
+	keys := make([]string, 0, len(t.reads))
+	for key := range t.reads {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !t.client.CompareAndSet(key, t.reads[key].ver+1, t.intent(key)) {
+			proposerLog.Infof("Txn: %s conflicts on key %s, abort", t.id, key)
+			t.client.decideTxn(context.Background(), t.id, &Value{Vi64: TxnAborted})
+			return ErrTxnConflict
+		}
+	}
+
+	intents := make([]TxnIntent, 0, len(keys))
+	for _, key := range keys {
+		intents = append(intents, TxnIntent{Key: key, Ver: t.reads[key].ver + 1})
+	}
+	data, err := json.Marshal(intents)
+	if err != nil {
+		return err
+	}
+
+	if t.client.decideTxn(context.Background(), t.id, &Value{Vi64: TxnCommitted, Data: data}) != TxnCommitted {
+		proposerLog.Infof("Txn: %s has been aborted by others", t.id)
+		return ErrTxnConflict
+	}
+	return nil
+}
+
+// intent returns the intent to write on the key.
+func (t *Txn) intent(key string) *Value {
+	val, ok := t.writes[key]
+	if !ok {
+		val = t.reads[key].val
+	}
+	if val == nil {
+		val = &Value{Tombstone: true}
+	}
+
+	val = proto.Clone(val).(*Value)
+	val.TxnId = t.id
+	return val
+}
+
+// txnCommitted returns whether the transaction is committed. A pending
+// transaction is given TxnPendingTimeout to be decided before it is aborted,
+// so that reading or watching the keys does not abort the transactions
+// writing them.
+func (c *Client) txnCommitted(ctx context.Context, id string) (bool, error) {
+	for deadline := time.Now().Add(TxnPendingTimeout); time.Now().Before(deadline); {
+		if record, _ := c.propose(ctx, TxnRecordPrefix+id, 0, nil); record != nil {
+			return record.Vi64 == TxnCommitted, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return c.decideTxn(ctx, id, &Value{Vi64: TxnAborted}) == TxnCommitted, nil
+}
+
+// decideTxn proposes the decision on the transaction and returns the status
+// chosen, or 0 if the proposal is rejected, e.g. the record has been reclaimed.
+func (c *Client) decideTxn(ctx context.Context, id string, decision *Value) int64 {
+	val, _ := c.propose(ctx, TxnRecordPrefix+id, 0, decision)
+	return val.GetVi64()
+}
+
+// intentsReclaimed reports whether every intent listed in the record of a
+// committed transaction has been reclaimed by a quorum of Acceptors.
+func (c *Client) intentsReclaimed(record *Value) (bool, error) {
+	var intents []TxnIntent
+	if err := json.Unmarshal(record.Data, &intents); err != nil {
+		return false, err
+	}
+
+	quorum := len(c.AcceptorIds)/2 + 1
+	for _, intent := range intents {
+		var count int
+		for _, aid := range c.AcceptorIds {
+			reply, err := c.transport().LatestVersion(context.Background(), aid,
+				&LatestVersionRequest{Key: intent.Key, Namespace: c.Namespace})
+			if err == nil && reply.Floor > intent.Ver {
+				count += 1
+			}
+		}
+		if count < quorum {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,246 @@
+package core
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func transfer(client *Client, from, to string, amount int64) error {
+	txn := client.Begin()
+	src, err := txn.Get(from)
+	if err != nil {
+		return err
+	}
+	dst, err := txn.Get(to)
+	if err != nil {
+		return err
+	}
+	if err = txn.Set(from, &Value{Vi64: src.Vi64 - amount}); err != nil {
+		return err
+	}
+	if err = txn.Set(to, &Value{Vi64: dst.Vi64 + amount}); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+func balance(r *require.Assertions, client *Client, key string) int64 {
+	val, _, err := client.Get(key)
+	r.Nil(err)
+	return val.Vi64
+}
+
+func TestTxn_CommitAndConflict(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	client := NewClient(acceptorIds, 2)
+	_, _ = client.Set("a", &Value{Vi64: 100})
+	_, _ = client.Set("b", &Value{Vi64: 0})
+
+	r.Nil(transfer(client, "a", "b", 10))
+	r.Equal(int64(90), balance(r, client, "a"))
+	r.Equal(int64(10), balance(r, client, "b"))
+
+	// x and y both read a, y commits after a is changed by x
+	x, y := client.Begin(), client.Begin()
+	_, err := x.Get("a")
+	r.Nil(err)
+	_, err = y.Get("a")
+	r.Nil(err)
+	r.Nil(x.Set("a", &Value{Vi64: 1}))
+	r.Nil(y.Set("c", &Value{Vi64: 2}))
+	r.Nil(x.Commit())
+	r.Equal(ErrTxnConflict, y.Commit())
+
+	r.Equal(int64(1), balance(r, client, "a"))
+	_, _, err = client.Get("c")
+	r.Equal(ErrNotFound, err)
+}
+
+func TestTxn_AbandonedIntentIsAborted(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	client := NewClient(acceptorIds, 2)
+	_, _ = client.Set("a", &Value{Vi64: 100})
+
+	// a coordinator crashes after writing its intent
+	r.True(client.CompareAndSet("a", 1, &Value{Vi64: 0, TxnId: "crashed"}))
+
+	val, ver, err := client.Get("a")
+	r.Nil(err)
+	r.Equal(int64(100), val.Vi64)
+	r.Equal(int64(1), ver)
+	r.Equal(TxnAborted, client.Read(TxnRecordPrefix+"crashed", 0).Vi64)
+
+	// the version of the aborted intent is skipped by later writes
+	ver, err = client.Set("a", &Value{Vi64: 50})
+	r.Nil(err)
+	r.Equal(int64(2), ver)
+}
+
+func TestTxn_PendingIntentIsGivenTime(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	client := NewClient(acceptorIds, 2)
+	_, _ = client.Set("a", &Value{Vi64: 100})
+
+	// the coordinator commits shortly after a reader runs into its intent
+	r.True(client.CompareAndSet("a", 1, &Value{Vi64: 0, TxnId: "slow"}))
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		client.decideTxn(context.Background(), "slow", &Value{Vi64: TxnCommitted})
+	}()
+
+	val, ver, err := client.Get("a")
+	r.Nil(err)
+	r.Equal(int64(0), val.Vi64)
+	r.Equal(int64(1), ver)
+	r.Equal(TxnCommitted, client.Read(TxnRecordPrefix+"slow", 0).Vi64)
+}
+
+func TestTxn_ConcurrentTransfers(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	accounts := []string{"x", "y", "z"}
+	for _, key := range accounts {
+		_, _ = NewClient(acceptorIds, 0).Set(key, &Value{Vi64: 100})
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var committed int
+	for i := 1; i <= 4; i++ {
+		wg.Add(1)
+		go func(pid int64) {
+			defer wg.Done()
+
+			client := NewClient(acceptorIds, pid)
+			rnd := rand.New(rand.NewSource(pid))
+			for n := 0; n < 3; n++ {
+				from, to := accounts[rnd.Intn(3)], accounts[rnd.Intn(3)]
+				if from == to {
+					continue
+				}
+				if err := transfer(client, from, to, 1); err == nil {
+					mu.Lock()
+					committed += 1
+					mu.Unlock()
+				}
+				time.Sleep(time.Duration(rnd.Intn(5)) * time.Millisecond)
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+
+	// transfers are atomic whatever transactions are aborted
+	client := NewClient(acceptorIds, 5)
+	var sum int64
+	for _, key := range accounts {
+		sum += balance(r, client, key)
+	}
+	r.Equal(int64(300), sum)
+	t.Logf("%d transfers committed", committed)
+}
+
+func TestTxn_IntentsOnReadPaths(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+	collectGarbage := func() {
+		for _, kv := range servers.KVServers() {
+			kv.CollectGarbage()
+		}
+	}
+
+	learner := NewLearner(acceptorIds)
+	learner.Start()
+	defer learner.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := NewClient(acceptorIds, 2)
+	_, _ = client.Set("a", &Value{Vi64: 100})
+	ch, err := client.Watch(ctx, "a", false, 0)
+	r.Nil(err)
+	r.Equal(int64(100), receive(t, ch).Val.Vi64)
+
+	// the version a pending intent falls back to is not reclaimed
+	r.True(client.CompareAndSet("a", 1, &Value{Vi64: 0, TxnId: "crashed"}))
+	collectGarbage()
+
+	// scans resolve the intent, aborting the transaction
+	reply, err := client.Scan(0, "", "", 0, "")
+	r.Nil(err)
+	r.Len(reply.Instances, 1)
+	r.Equal(int64(1), reply.Instances[0].Id.Ver)
+	r.Equal(int64(100), reply.Instances[0].Val.Vi64)
+
+	// the record is hidden from scans unless asked for
+	reply, err = client.Scan(0, "", "", 0, "")
+	r.Nil(err)
+	r.Equal([]string{"a"}, scanKeys(reply))
+	reply, err = client.ListPrefix(0, TxnRecordPrefix, 0, "")
+	r.Nil(err)
+	r.Equal([]string{TxnRecordPrefix + "crashed"}, scanKeys(reply))
+
+	// watchers and learners skip the aborted intent
+	_, err = client.Set("a", &Value{Vi64: 50})
+	r.Nil(err)
+	inst := receive(t, ch)
+	r.Equal(int64(2), inst.Id.Ver)
+	r.Equal(int64(50), inst.Val.Vi64)
+	r.Eventually(func() bool {
+		inst, _, ok := learner.Get("a")
+		return ok && inst.Val.Vi64 == 50
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// the record of the aborted transaction is reclaimed at once
+	sweeper := NewSweeper(client, time.Second, 0)
+	r.Equal(1, sweeper.SweepTxns())
+	collectGarbage()
+	r.Nil(client.Read(TxnRecordPrefix+"crashed", 0))
+
+	// the record of a committed transaction is kept until its intents are superseded
+	_, _ = client.Set("b", &Value{Vi64: 0})
+	r.Nil(transfer(client, "a", "b", 10))
+	r.Eventually(func() bool {
+		inst, _, ok := learner.Get("b")
+		return ok && inst.Val.Vi64 == 10
+	}, 3*time.Second, 10*time.Millisecond)
+	collectGarbage()
+	r.Equal(0, sweeper.SweepTxns())
+	r.Equal(int64(40), balance(r, client, "a"))
+
+	_, _ = client.Set("a", &Value{Vi64: 1})
+	_, _ = client.Set("b", &Value{Vi64: 2})
+	collectGarbage()
+	r.Equal(1, sweeper.SweepTxns())
+	r.Equal(int64(1), balance(r, client, "a"))
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestNextVersion(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	ver, err := NextVersion(acceptorIds, "k")
+	r.Nil(err)
+	r.Equal(int64(0), ver)
+
+	set(acceptorIds, "k", 0, 5)
+	set(acceptorIds, "k", 1, 6)
+
+	ver, err = NextVersion(acceptorIds, "k")
+	r.Nil(err)
+	r.Equal(int64(2), ver)
+
+	// a single Acceptor can not constitute a quorum
+	servers.Server(0).Stop()
+	servers.Server(1).Stop()
+	_, err = NextVersion(acceptorIds, "k")
+	r.Equal(ErrNoEnoughQuorum, err)
+}
@@ -0,0 +1,198 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// WatchRetryInterval is the interval a watcher waits before reconnecting to the next Acceptor.
+var WatchRetryInterval = 200 * time.Millisecond
+
+// Watch streams the chosen versions of a key in the namespace of the Client,
+// or of all the keys with `key` as prefix if `prefix` is set, starting from
+// version fromVer. It returns ErrNoAcceptors if the Client has no Acceptors.
+//
+// Versions of every key are delivered in increasing order and at most once.
+// The stream follows the commit log of one Acceptor, in which versions may be
+// out of order or missing, since Commits are best-effort. A gap before a
+// received version is filled by reading the missing versions from a quorum,
+// a version found not chosen by the read is skipped. The Index of an instance
+// read from a quorum is 0. Intents of transactions not committed are skipped,
+// see Txn, a pending transaction is aborted if it is not decided in
+// TxnPendingTimeout.
+//
+// When the stream from an Acceptor breaks, Watch switches to the next
+// Acceptor, and first delivers the versions of the keys seen so far up to the
+// latest one voted by a quorum, so that the versions the next Acceptor has
+// not learned are not lost. The returned channel is closed when ctx is done.
+func (c *Client) Watch(ctx context.Context, key string, prefix bool, fromVer int64) (<-chan *Instance, error) {
+	if len(c.AcceptorIds) == 0 {
+		return nil, ErrNoAcceptors
+	}
+
+	w := &watcher{client: c, key: key, prefix: prefix, fromVer: fromVer,
+		ch: make(chan *Instance), next: map[string]int64{}}
+	if !prefix {
+		w.next[key] = fromVer
+	}
+	go w.run(ctx)
+	return w.ch, nil
+}
+
+// watcher delivers the chosen versions of the keys watched in order.
+type watcher struct {
+	client  *Client
+	key     string
+	prefix  bool
+	fromVer int64
+
+	ch chan *Instance
+	// next stores the next version to deliver of every key seen.
+	next map[string]int64
+}
+
+func (w *watcher) run(ctx context.Context) {
+	defer close(w.ch)
+
+	acceptorIds := w.client.AcceptorIds
+	for i := 0; ; i++ {
+		aid := acceptorIds[i%len(acceptorIds)]
+
+		var err error
+		if i > 0 {
+			err = w.catchUp(ctx)
+		}
+		if err == nil {
+			req := &WatchRequest{Key: w.key, Prefix: w.prefix, FromVer: w.fromVer, Namespace: w.client.Namespace}
+			if !w.prefix {
+				req.FromVer = w.next[w.key]
+			}
+			err = watchAcceptor(ctx, aid, req, func(inst *Instance) error {
+				return w.deliver(ctx, inst)
+			})
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		transportLog.Errorf("Watcher: watch on Acceptor-%d broken: %v", aid, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(WatchRetryInterval):
+		}
+	}
+}
+
+// deliver delivers an instance received from an Acceptor, after the versions
+// of the key missing before it.
+func (w *watcher) deliver(ctx context.Context, inst *Instance) error {
+	key := inst.Id.Key
+	next, ok := w.next[key]
+	if !ok {
+		next = w.fromVer
+	}
+	if inst.Id.Ver < next {
+		return nil
+	}
+
+	if err := w.fill(ctx, key, next, inst.Id.Ver); err != nil {
+		return err
+	}
+	return w.send(ctx, inst)
+}
+
+// catchUp delivers the versions of the keys seen so far up to the latest one
+// voted by a quorum.
+func (w *watcher) catchUp(ctx context.Context) error {
+	for key, next := range w.next {
+		latest, _, err := quorumVersions(ctx, w.client.transport(), w.client.AcceptorIds, w.client.Namespace, key)
+		if err != nil {
+			return err
+		}
+		if err := w.fill(ctx, key, next, latest+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fill delivers the chosen versions of the key in [from, to) read from a quorum.
+func (w *watcher) fill(ctx context.Context, key string, from, to int64) error {
+	for ver := from; ver < to; ver++ {
+		val, err := w.client.propose(ctx, key, ver, nil)
+		if err == ErrCompacted {
+			// Skip the versions reclaimed.
+			_, floor, err := quorumVersions(ctx, w.client.transport(), w.client.AcceptorIds, w.client.Namespace, key)
+			if err != nil {
+				return err
+			}
+			if floor-1 > ver {
+				ver = floor - 1
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if val == nil {
+			continue
+		}
+
+		inst := &Instance{Id: &PaxosInstanceId{Key: key, Ver: ver, Namespace: w.client.Namespace}, Val: val}
+		if err := w.send(ctx, inst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *watcher) send(ctx context.Context, inst *Instance) error {
+	// The intent of a transaction not committed does not take effect.
+	if inst.Val.TxnId != "" {
+		committed, err := w.client.txnCommitted(ctx, inst.Val.TxnId)
+		if err != nil {
+			return err
+		}
+		if !committed {
+			w.next[inst.Id.Key] = inst.Id.Ver + 1
+			return nil
+		}
+	}
+
+	select {
+	case w.ch <- inst:
+		w.next[inst.Id.Key] = inst.Id.Ver + 1
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// watchAcceptor runs a Watch RPC on an Acceptor, calling deliver for every
+// received instance until the stream breaks or deliver fails.
+func watchAcceptor(ctx context.Context, aid int64, req *WatchRequest, deliver func(*Instance) error) error {
+	conn, err := dialAcceptor(aid)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stream, err := NewPaxosKVClient(conn).Watch(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for {
+		inst, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if err := deliver(inst); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func set(acceptorIds []int64, key string, ver int64, val int64) {
+	prop := Proposer{
+		Id:  &PaxosInstanceId{Key: key, Ver: ver},
+		Bal: &BallotNum{N: 0, ProposerId: 2},
+	}
+	prop.RunPaxos(acceptorIds, &Value{Vi64: val})
+}
+
+func receive(t *testing.T, ch <-chan *Instance) *Instance {
+	select {
+	case inst := <-ch:
+		return inst
+	case <-time.After(3 * time.Second):
+		t.Fatal("no instance received")
+		return nil
+	}
+}
+
+func TestWatch_KeyResumeAfterDisconnect(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	set(acceptorIds, "k", 0, 5)
+
+	// version 0 is skipped
+	ch, err := NewClient(acceptorIds, 1).Watch(ctx, "k", false, 1)
+	r.Nil(err)
+
+	set(acceptorIds, "k", 1, 6)
+	inst := receive(t, ch)
+	r.Equal(int64(1), inst.Id.Ver)
+	r.Equal(int64(6), inst.Val.Vi64)
+
+	// the watcher switches to Acceptor-1 and does not deliver version 1 again
+	servers.Server(0).Stop()
+	set(acceptorIds, "k", 2, 7)
+	inst = receive(t, ch)
+	r.Equal(int64(2), inst.Id.Ver)
+	r.Equal(int64(7), inst.Val.Vi64)
+}
+
+func TestWatch_Prefix(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := NewClient(acceptorIds, 1).Watch(ctx, "user/", true, 0)
+	r.Nil(err)
+
+	set(acceptorIds, "user/a", 0, 1)
+	set(acceptorIds, "order/a", 0, 2)
+	set(acceptorIds, "user/b", 0, 3)
+
+	r.Equal("user/a", receive(t, ch).Id.Key)
+	r.Equal("user/b", receive(t, ch).Id.Key)
+}
+
+func TestWatch_FillGaps(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+	servers := ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := (&Client{}).Watch(ctx, "k", false, 0)
+	r.Equal(ErrNoAcceptors, err)
+
+	ch, err := NewClient(acceptorIds, 1).Watch(ctx, "k", false, 0)
+	r.Nil(err)
+
+	set(acceptorIds, "k", 0, 5)
+	r.Equal(int64(0), receive(t, ch).Id.Ver)
+
+	// version 1 is chosen without Acceptor-0, whose commit log misses it
+	set([]int64{1, 2}, "k", 1, 6)
+	set(acceptorIds, "k", 2, 7)
+
+	inst := receive(t, ch)
+	r.Equal(int64(1), inst.Id.Ver)
+	r.Equal(int64(6), inst.Val.Vi64)
+	inst = receive(t, ch)
+	r.Equal(int64(2), inst.Id.Ver)
+	r.Equal(int64(7), inst.Val.Vi64)
+
+	// version 3 is chosen by Acceptor-1 and Acceptor-2 but committed nowhere,
+	// the watcher reads it once it switches to Acceptor-1
+	p := Proposer{Id: &PaxosInstanceId{Key: "k", Ver: 3}, Bal: &BallotNum{ProposerId: 2}}
+	_, _, err = p.Phase1([]int64{1, 2}, 2)
+	r.Nil(err)
+	p.Val = &Value{Vi64: 8}
+	_, err = p.Phase2([]int64{1, 2}, 2)
+	r.Nil(err)
+	servers.Server(0).Stop()
+	inst = receive(t, ch)
+	r.Equal(int64(3), inst.Id.Ver)
+	r.Equal(int64(8), inst.Val.Vi64)
+}
@@ -0,0 +1,188 @@
+package history
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// The kinds of operations.
+const (
+	OpGet    = "get"
+	OpSet    = "set"
+	OpDelete = "delete"
+)
+
+// Input is an operation on a key, Value is the value to set.
+type Input struct {
+	Op    string
+	Key   string
+	Value int64
+}
+
+// Output is the result of an operation, Value is the value got if Found.
+type Output struct {
+	Value int64
+	Found bool
+}
+
+// Recorder records the history of the operations of concurrent clients.
+//
+// Operations are stamped by a logical clock, shared by the clients, when they
+// are called and when they return. A write failing may or may not take effect,
+// it is recorded as never returning, so that it can be linearized at any point
+// after it is called. A read failing has no effect, it is not recorded.
+type Recorder struct {
+	clock int64
+
+	mu         sync.Mutex
+	operations []porcupine.Operation
+}
+
+// NewRecorder creates a Recorder with an empty history.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Record runs and records an operation of the client, clientId is zero-indexed.
+func (r *Recorder) Record(clientId int, in Input, run func() (Output, error)) error {
+	call := atomic.AddInt64(&r.clock, 1)
+	out, err := run()
+	ret := atomic.AddInt64(&r.clock, 1)
+
+	if err != nil {
+		if in.Op == OpGet {
+			return err
+		}
+		ret = math.MaxInt64
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operations = append(r.operations, porcupine.Operation{
+		ClientId: clientId,
+		Input:    in,
+		Call:     call,
+		Output:   out,
+		Return:   ret,
+	})
+	return err
+}
+
+// Get gets the key with the client and records it.
+func (r *Recorder) Get(clientId int, c *core.Client, key string) (*core.Value, error) {
+	var val *core.Value
+	err := r.Record(clientId, Input{Op: OpGet, Key: key}, func() (Output, error) {
+		var err error
+		val, _, err = c.Get(key)
+		if err == core.ErrNotFound {
+			return Output{}, nil
+		}
+		if err != nil {
+			return Output{}, err
+		}
+		return Output{Value: val.Vi64, Found: true}, nil
+	})
+	return val, err
+}
+
+// Set sets the key to the value with the client and records it.
+func (r *Recorder) Set(clientId int, c *core.Client, key string, value int64) error {
+	return r.Record(clientId, Input{Op: OpSet, Key: key, Value: value}, func() (Output, error) {
+		_, err := c.Set(key, &core.Value{Vi64: value})
+		return Output{}, err
+	})
+}
+
+// Delete deletes the key with the client and records it.
+func (r *Recorder) Delete(clientId int, c *core.Client, key string) error {
+	return r.Record(clientId, Input{Op: OpDelete, Key: key}, func() (Output, error) {
+		_, err := c.Delete(key)
+		return Output{}, err
+	})
+}
+
+// Operations returns the operations recorded.
+func (r *Recorder) Operations() []porcupine.Operation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]porcupine.Operation(nil), r.operations...)
+}
+
+// Check checks whether the history recorded is linearizable against KVModel.
+// It returns porcupine.Unknown if the check does not finish in timeout, 0 for
+// no timeout. The returned info visualizes the history with Visualize.
+func (r *Recorder) Check(timeout time.Duration) (porcupine.CheckResult, porcupine.LinearizationInfo) {
+	return porcupine.CheckOperationsVerbose(KVModel, r.Operations(), timeout)
+}
+
+// Visualize writes the history and its partial linearizations as an HTML file.
+func Visualize(info porcupine.LinearizationInfo, path string) error {
+	return porcupine.VisualizePath(KVModel, info, path)
+}
+
+// KVModel is the sequential specification of a key-value store, each key is a
+// register checked separately. The state of a key is an Output.
+var KVModel = porcupine.Model{
+	Partition: func(history []porcupine.Operation) [][]porcupine.Operation {
+		var keys []string
+		byKey := map[string][]porcupine.Operation{}
+		for _, op := range history {
+			key := op.Input.(Input).Key
+			if _, ok := byKey[key]; !ok {
+				keys = append(keys, key)
+			}
+			byKey[key] = append(byKey[key], op)
+		}
+
+		var partitions [][]porcupine.Operation
+		for _, key := range keys {
+			partitions = append(partitions, byKey[key])
+		}
+		return partitions
+	},
+	Init: func() interface{} {
+		return Output{}
+	},
+	Step: func(state, input, output interface{}) (bool, interface{}) {
+		in := input.(Input)
+		switch in.Op {
+		case OpSet:
+			return true, Output{Value: in.Value, Found: true}
+		case OpDelete:
+			return true, Output{}
+		default:
+			return output.(Output) == state.(Output), state
+		}
+	},
+	DescribeOperation: func(input, output interface{}) string {
+		in := input.(Input)
+		switch in.Op {
+		case OpSet:
+			return fmt.Sprintf("set(%s, %d)", in.Key, in.Value)
+		case OpDelete:
+			return fmt.Sprintf("delete(%s)", in.Key)
+		default:
+			return fmt.Sprintf("get(%s) -> %s", in.Key, describe(output.(Output)))
+		}
+	},
+	DescribeState: func(state interface{}) string {
+		return describe(state.(Output))
+	},
+}
+
+func describe(out Output) string {
+	if !out.Found {
+		return "<none>"
+	}
+	return fmt.Sprint(out.Value)
+}
@@ -0,0 +1,191 @@
+package history
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestKVModel(t *testing.T) {
+	r := require.New(t)
+
+	set := func(client int, key string, value, call, ret int64) porcupine.Operation {
+		return porcupine.Operation{ClientId: client, Input: Input{Op: OpSet, Key: key, Value: value}, Call: call, Output: Output{}, Return: ret}
+	}
+	get := func(client int, key string, out Output, call, ret int64) porcupine.Operation {
+		return porcupine.Operation{ClientId: client, Input: Input{Op: OpGet, Key: key}, Call: call, Output: out, Return: ret}
+	}
+
+	// a read concurrent with a write may see either value
+	r.True(porcupine.CheckOperations(KVModel, []porcupine.Operation{
+		set(0, "k", 1, 1, 2),
+		set(0, "k", 2, 3, 6),
+		get(1, "k", Output{Value: 1, Found: true}, 4, 5),
+		get(2, "k", Output{Value: 2, Found: true}, 7, 8),
+	}))
+
+	// a read after a write returns may not see the value before it
+	r.False(porcupine.CheckOperations(KVModel, []porcupine.Operation{
+		set(0, "k", 1, 1, 2),
+		set(0, "k", 2, 3, 4),
+		get(1, "k", Output{Value: 1, Found: true}, 5, 6),
+	}))
+
+	// a write failing may take effect at any time after it is called
+	r.True(porcupine.CheckOperations(KVModel, []porcupine.Operation{
+		set(0, "k", 1, 1, math.MaxInt64),
+		get(1, "k", Output{}, 2, 3),
+		get(1, "k", Output{Value: 1, Found: true}, 4, 5),
+		get(2, "x", Output{}, 6, 7),
+	}))
+}
+
+func TestRecorder_ConcurrentClients(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{40, 41, 42}
+	servers := core.ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	faults := core.NewFaults(1)
+	faults.Set(core.Fault{Drop: 0.05, DropReply: 0.05, Duplicate: 0.1}, acceptorIds...)
+
+	const clients, opsPerClient = 5, 30
+	keys := []string{"a", "b", "c"}
+	recorder := NewRecorder()
+
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(i)))
+			client := core.NewClient(acceptorIds, int64(i+1))
+			client.Transport = faults.Transport(nil)
+			for n := 0; n < opsPerClient; n++ {
+				key := keys[rng.Intn(len(keys))]
+				switch x := rng.Intn(10); {
+				case x < 5:
+					_, _ = recorder.Get(i, client, key)
+				case x < 9:
+					_ = recorder.Set(i, client, key, int64(i*opsPerClient+n+1))
+				default:
+					_ = recorder.Delete(i, client, key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	checkHistory(t, r, recorder)
+}
+
+func TestRecorder_PartitionCrash(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{40, 41, 42}
+	servers := core.ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	const clients, opsPerStep = 4, 10
+	keys := []string{"a", "b"}
+	recorder := NewRecorder()
+	faults := core.NewFaults(1)
+
+	// partition and crash a minority, then a majority, while the clients run
+	schedule := []func() error{
+		func() error { faults.Partition(40); return nil },
+		func() error { faults.Heal(); servers.Server(41).Stop(); return nil },
+		func() error { faults.Partition(40); return nil },
+		func() error { faults.Heal(); return servers.Server(41).Restart() },
+		func() error { servers.Server(42).Stop(); return nil },
+		func() error { return servers.Server(42).Restart() },
+	}
+
+	// next returns the index of the next operation, or false once all are
+	// started. A step of the schedule is taken after every opsPerStep
+	// operations started, whatever the timing of the clients is.
+	var mu sync.Mutex
+	var started int
+	var errs []error
+	next := func() (int, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if started == (len(schedule)+1)*opsPerStep {
+			return 0, false
+		}
+		n := started
+		started += 1
+		if step := n / opsPerStep; n%opsPerStep == 0 && step > 0 {
+			if err := schedule[step-1](); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return n, true
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(i)))
+			client := core.NewClient(acceptorIds, int64(i+1))
+			client.Transport = faults.Transport(nil)
+			for {
+				n, ok := next()
+				if !ok {
+					return
+				}
+				key := keys[rng.Intn(len(keys))]
+				if n%2 == 0 {
+					_, _ = recorder.Get(i, client, key)
+				} else {
+					_ = recorder.Set(i, client, key, int64(n+1))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	r.Empty(errs)
+
+	// the writes started without a quorum fail, and may take effect later
+	var failed int
+	for _, op := range recorder.Operations() {
+		if op.Return == math.MaxInt64 {
+			failed += 1
+		}
+	}
+	r.Positive(failed)
+	checkHistory(t, r, recorder)
+}
+
+// checkHistory checks the history recorded is linearizable. If it is not,
+// the history is visualized as an HTML file in $PAXOSKV_HISTORY_DIR, or the
+// temporary directory of the system if unset, which is kept after the test.
+func checkHistory(t *testing.T, r *require.Assertions, recorder *Recorder) {
+	r.NotEmpty(recorder.Operations())
+	result, info := recorder.Check(10 * time.Second)
+	if result == porcupine.Ok {
+		return
+	}
+
+	f, err := os.CreateTemp(os.Getenv("PAXOSKV_HISTORY_DIR"), "history-*.html")
+	r.Nil(err)
+	r.Nil(f.Close())
+	r.Nil(Visualize(info, f.Name()))
+	t.Logf("history visualized in %s", f.Name())
+	r.Fail(fmt.Sprintf("history is not linearizable: %v, see %s", result, f.Name()))
+}
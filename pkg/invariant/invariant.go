@@ -0,0 +1,426 @@
+package invariant
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// The safety invariants of paxos checked.
+const (
+	OneValueChosen   = "at most one value is chosen per instance"
+	LastBalMonotonic = "LastBal never decreases"
+	VBalBelowLastBal = "VBal <= LastBal"
+	ChosenProposed   = "every chosen value was proposed"
+)
+
+// Violation is a violated invariant on an instance.
+type Violation struct {
+	Invariant string
+	Id        *core.PaxosInstanceId
+	// Acceptor is the Acceptor the violation is seen on, -1 if it is seen on
+	// a quorum or a Proposer.
+	Acceptor int64
+	Detail   string
+	// Trace is the trace of the instance leading to the violation: the changes
+	// of the states of the Acceptors on the instance, each with the note of the
+	// observation it is seen at, e.g. the simulated event making it.
+	//
+	// The trace is shrunk: changes on other instances are left out, and so is
+	// every change the violation is still reproduced without when the rest are
+	// replayed, found by delta debugging.
+	Trace []string
+}
+
+func (v *Violation) Error() string {
+	where := fmt.Sprintf("%s@%d", v.Id.GetKey(), v.Id.GetVer())
+	if v.Acceptor >= 0 {
+		where += fmt.Sprintf(" on Acceptor-%d", v.Acceptor)
+	}
+	return fmt.Sprintf("invariant violated: %s: %s: %s\n  %s",
+		v.Invariant, where, v.Detail, strings.Join(v.Trace, "\n  "))
+}
+
+type instanceKey struct {
+	namespace string
+	key       string
+	ver       int64
+}
+
+type stateKey struct {
+	aid int64
+	instanceKey
+}
+
+type recordKey struct {
+	namespace string
+	key       string
+}
+
+type ballotKey struct {
+	n          int64
+	proposerId int64
+}
+
+// change is a change of the state of an Acceptor on an instance, or a value
+// decided by a Proposer if state is nil, replayed to shrink a violation.
+type change struct {
+	note    string
+	aid     int64
+	state   *core.InstanceState
+	decided *core.Value
+}
+
+// Checker checks the safety invariants of paxos on the states of a group of
+// Acceptors observed over time, e.g. after every simulated event, or
+// periodically while clients run against real gRPC servers.
+//
+// A value is chosen on an instance once it is voted by a quorum at the same
+// ballot, learned by an Acceptor, or decided by a Proposer. The values must be
+// proposed on the key beforehand with Proposed.
+//
+// The first violation is kept, the later observations return it as well.
+type Checker struct {
+	quorum int
+
+	mu        sync.Mutex
+	states    map[stateKey]*core.InstanceState
+	chosen    map[instanceKey]*core.Value
+	proposed  map[recordKey][]*core.Value
+	traces    map[instanceKey][]string
+	changes   map[instanceKey][]change
+	violation *Violation
+}
+
+// New creates a Checker of a group of n Acceptors.
+func New(n int) *Checker {
+	return newChecker(n/2+1, map[recordKey][]*core.Value{})
+}
+
+func newChecker(quorum int, proposed map[recordKey][]*core.Value) *Checker {
+	return &Checker{
+		quorum:   quorum,
+		states:   map[stateKey]*core.InstanceState{},
+		chosen:   map[instanceKey]*core.Value{},
+		proposed: proposed,
+		traces:   map[instanceKey][]string{},
+		changes:  map[instanceKey][]change{},
+	}
+}
+
+// Proposed records a value proposed on any version of the key, a nil value,
+// i.e. of a read, is ignored.
+func (c *Checker) Proposed(namespace, key string, val *core.Value) {
+	if val == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rk := recordKey{namespace, key}
+	c.proposed[rk] = append(c.proposed[rk], proto.Clone(val).(*core.Value))
+}
+
+// Decided checks a value a Proposer returns as chosen on the instance.
+func (c *Checker) Decided(id *core.PaxosInstanceId, val *core.Value) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.violation == nil && val != nil {
+		ik := instanceKey{id.Namespace, id.Key, id.Ver}
+		c.decide(ik, val)
+		c.shrink()
+	}
+	return c.err()
+}
+
+// Observe takes the states of the Acceptors and checks the invariants, note
+// describes the observation in the trace.
+func (c *Checker) Observe(note string, acceptors map[int64]*core.KVServer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.violation == nil {
+		c.observeAll(note, acceptors)
+		c.shrink()
+	}
+	return c.err()
+}
+
+// observeAll takes the states of the Acceptors and checks the invariants.
+// c.mu must be held.
+func (c *Checker) observeAll(note string, acceptors map[int64]*core.KVServer) {
+	var aids []int64
+	for aid := range acceptors {
+		aids = append(aids, aid)
+	}
+	sort.Slice(aids, func(i, j int) bool { return aids[i] < aids[j] })
+
+	var instances []instanceKey
+	states := map[instanceKey][]*core.Acceptor{}
+	for _, aid := range aids {
+		for _, st := range acceptors[aid].Snapshot() {
+			ik := instanceKey{st.Id.Namespace, st.Id.Key, st.Id.Ver}
+			if c.observe(note, aid, ik, st); c.violation != nil {
+				return
+			}
+
+			if _, ok := states[ik]; !ok {
+				instances = append(instances, ik)
+			}
+			states[ik] = append(states[ik], st.Acceptor)
+		}
+	}
+
+	for _, ik := range instances {
+		c.chooseVoted(ik, states[ik])
+	}
+}
+
+// Poll observes the Acceptors every interval until ctx is done or an
+// invariant is violated, and returns the violation if any.
+func (c *Checker) Poll(ctx context.Context, interval time.Duration, acceptors map[int64]*core.KVServer) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for n := 1; ; n++ {
+		if err := c.Observe(fmt.Sprintf("poll #%d", n), acceptors); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Err returns the violation found so far, nil if none.
+func (c *Checker) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err()
+}
+
+func (c *Checker) err() error {
+	if c.violation == nil {
+		return nil
+	}
+	return c.violation
+}
+
+// observe checks the state of an Acceptor on an instance against the previous
+// one observed, and traces it if it changes. c.mu must be held.
+func (c *Checker) observe(note string, aid int64, ik instanceKey, st *core.InstanceState) {
+	sk := stateKey{aid, ik}
+	prev := c.states[sk]
+	if prev == nil || !proto.Equal(prev.Acceptor, st.Acceptor) || !proto.Equal(prev.Chosen, st.Chosen) {
+		c.traces[ik] = append(c.traces[ik], fmt.Sprintf("%s: Acceptor-%d %s", note, aid, describe(st)))
+		c.changes[ik] = append(c.changes[ik], change{note: note, aid: aid, state: st})
+	}
+	c.states[sk] = st
+
+	a := st.Acceptor
+	if !a.LastBal.GE(a.VBal) {
+		c.violate(VBalBelowLastBal, ik, aid, fmt.Sprintf("VBal %d.%d > LastBal %d.%d",
+			a.VBal.N, a.VBal.ProposerId, a.LastBal.N, a.LastBal.ProposerId))
+		return
+	}
+	if prev != nil && !a.LastBal.GE(prev.Acceptor.LastBal) {
+		c.violate(LastBalMonotonic, ik, aid, fmt.Sprintf("LastBal %d.%d decreases to %d.%d",
+			prev.Acceptor.LastBal.N, prev.Acceptor.LastBal.ProposerId, a.LastBal.N, a.LastBal.ProposerId))
+		return
+	}
+	if st.Chosen != nil {
+		c.choose(ik, aid, st.Chosen, fmt.Sprintf("learned by Acceptor-%d", aid))
+	}
+}
+
+// decide checks a value a Proposer decides on the instance. c.mu must be held.
+func (c *Checker) decide(ik instanceKey, val *core.Value) {
+	c.changes[ik] = append(c.changes[ik], change{aid: -1, decided: val})
+	c.choose(ik, -1, val, "decided by a Proposer")
+}
+
+// chooseVoted checks the values voted by a quorum of the states of the
+// Acceptors on the instance at the same ballot. c.mu must be held.
+func (c *Checker) chooseVoted(ik instanceKey, states []*core.Acceptor) {
+	votes := map[ballotKey][]*core.Value{}
+	var ballots []ballotKey
+	for _, a := range states {
+		if a.Val == nil {
+			continue
+		}
+		bk := ballotKey{a.VBal.N, a.VBal.ProposerId}
+		if _, ok := votes[bk]; !ok {
+			ballots = append(ballots, bk)
+		}
+		votes[bk] = append(votes[bk], a.Val)
+	}
+	sort.Slice(ballots, func(i, j int) bool {
+		return ballots[i].n < ballots[j].n || ballots[i].n == ballots[j].n && ballots[i].proposerId < ballots[j].proposerId
+	})
+
+	for _, bk := range ballots {
+		if vals := votes[bk]; len(vals) >= c.quorum {
+			c.choose(ik, -1, vals[0], fmt.Sprintf("voted by a quorum at ballot %d.%d", bk.n, bk.proposerId))
+		}
+	}
+}
+
+// choose checks a value chosen on the instance, aid is the Acceptor it is
+// seen on, -1 for none. c.mu must be held.
+func (c *Checker) choose(ik instanceKey, aid int64, val *core.Value, how string) {
+	if c.violation != nil {
+		return
+	}
+
+	if chosen, ok := c.chosen[ik]; ok {
+		if !proto.Equal(chosen, val) {
+			c.violate(OneValueChosen, ik, aid, fmt.Sprintf("%v %s, but %v has been chosen", val, how, chosen))
+		}
+		return
+	}
+
+	var proposed bool
+	for _, v := range c.proposed[recordKey{ik.namespace, ik.key}] {
+		if proto.Equal(v, val) {
+			proposed = true
+			break
+		}
+	}
+	if !proposed {
+		c.violate(ChosenProposed, ik, aid, fmt.Sprintf("%v %s, but it has never been proposed", val, how))
+		return
+	}
+
+	c.chosen[ik] = proto.Clone(val).(*core.Value)
+	c.traces[ik] = append(c.traces[ik], fmt.Sprintf("chosen: %v %s", val, how))
+}
+
+func (c *Checker) violate(invariant string, ik instanceKey, aid int64, detail string) {
+	c.violation = &Violation{
+		Invariant: invariant,
+		Id:        &core.PaxosInstanceId{Namespace: ik.namespace, Key: ik.key, Ver: ik.ver},
+		Acceptor:  aid,
+		Detail:    detail,
+		Trace:     append([]string(nil), c.traces[ik]...),
+	}
+}
+
+// shrink shrinks the trace of the violation just found to the changes it is
+// reproduced with, see Violation.Trace. c.mu must be held.
+func (c *Checker) shrink() {
+	v := c.violation
+	if v == nil {
+		return
+	}
+	ik := instanceKey{v.Id.Namespace, v.Id.Key, v.Id.Ver}
+	reproduces := func(changes []change) bool {
+		replayed := c.replay(ik, changes)
+		return replayed != nil && replayed.Invariant == v.Invariant
+	}
+
+	// The states are replayed without the Acceptors not observed, which may
+	// not reproduce the violation, then the trace is kept as is.
+	changes := c.changes[ik]
+	if !reproduces(changes) {
+		return
+	}
+	if shrunk := c.replay(ik, ddmin(changes, reproduces)); len(shrunk.Trace) < len(v.Trace) {
+		c.violation = shrunk
+	}
+}
+
+// replay checks the changes on the instance in order with a new Checker, and
+// returns the violation they lead to, nil if none.
+func (c *Checker) replay(ik instanceKey, changes []change) *Violation {
+	r := newChecker(c.quorum, c.proposed)
+	latest := map[int64]*core.Acceptor{}
+	var aids []int64
+	for _, ch := range changes {
+		if ch.state == nil {
+			r.decide(ik, ch.decided)
+			if r.violation != nil {
+				return r.violation
+			}
+			continue
+		}
+
+		if r.observe(ch.note, ch.aid, ik, ch.state); r.violation != nil {
+			return r.violation
+		}
+		if _, ok := latest[ch.aid]; !ok {
+			aids = append(aids, ch.aid)
+			sort.Slice(aids, func(i, j int) bool { return aids[i] < aids[j] })
+		}
+		latest[ch.aid] = ch.state.Acceptor
+
+		var states []*core.Acceptor
+		for _, aid := range aids {
+			states = append(states, latest[aid])
+		}
+		if r.chooseVoted(ik, states); r.violation != nil {
+			return r.violation
+		}
+	}
+	return nil
+}
+
+// ddmin returns a subsequence of the changes still reproducing a violation,
+// from which no single change can be removed, by delta debugging: it removes
+// chunks of the changes, halving the chunks until they are single changes.
+func ddmin(changes []change, reproduces func([]change) bool) []change {
+	n := 2
+	for len(changes) >= 2 {
+		size := (len(changes) + n - 1) / n
+		removed := false
+		for start := 0; start < len(changes); start += size {
+			end := start + size
+			if end > len(changes) {
+				end = len(changes)
+			}
+			rest := append(append([]change(nil), changes[:start]...), changes[end:]...)
+			if reproduces(rest) {
+				changes, removed = rest, true
+				if n > 2 {
+					n -= 1
+				}
+				break
+			}
+		}
+		if removed {
+			continue
+		}
+		if n >= len(changes) {
+			break
+		}
+		if n *= 2; n > len(changes) {
+			n = len(changes)
+		}
+	}
+	return changes
+}
+
+func describe(st *core.InstanceState) string {
+	a := st.Acceptor
+	s := fmt.Sprintf("last=%d.%d", a.LastBal.GetN(), a.LastBal.GetProposerId())
+	if a.Val != nil {
+		s += fmt.Sprintf(" voted=%v@%d.%d", a.Val, a.VBal.GetN(), a.VBal.GetProposerId())
+	}
+	if st.Chosen != nil {
+		s += fmt.Sprintf(" chosen=%v", st.Chosen)
+	}
+	return s
+}
@@ -0,0 +1,203 @@
+package invariant
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/khighness/highness-paxos-kv/core"
+	"github.com/khighness/highness-paxos-kv/pkg/sim"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+// accept makes the Acceptors vote the value at the ballot, skipping phase-1
+// like a broken Proposer.
+func accept(r *require.Assertions, kvs map[int64]*core.KVServer, bal int64, val int64, aids ...int64) {
+	req := &core.Proposer{
+		Id:  &core.PaxosInstanceId{Key: "k", Ver: 0},
+		Bal: &core.BallotNum{N: bal, ProposerId: bal},
+		Val: &core.Value{Vi64: val},
+	}
+	for _, aid := range aids {
+		_, err := kvs[aid].Accept(context.Background(), req)
+		r.Nil(err)
+	}
+}
+
+// prepare makes the Acceptors promise the ballot.
+func prepare(r *require.Assertions, kvs map[int64]*core.KVServer, bal int64, aids ...int64) {
+	req := &core.Proposer{
+		Id:  &core.PaxosInstanceId{Key: "k", Ver: 0},
+		Bal: &core.BallotNum{N: bal, ProposerId: bal},
+	}
+	for _, aid := range aids {
+		_, err := kvs[aid].Prepare(context.Background(), req)
+		r.Nil(err)
+	}
+}
+
+func newKVServers() map[int64]*core.KVServer {
+	return map[int64]*core.KVServer{0: core.NewKVServer(), 1: core.NewKVServer(), 2: core.NewKVServer()}
+}
+
+func TestChecker_Violations(t *testing.T) {
+	r := require.New(t)
+
+	// a Proposer skipping phase-1 overwrites the value chosen
+	kvs, checker := newKVServers(), New(3)
+	checker.Proposed("", "k", &core.Value{Vi64: 1})
+	checker.Proposed("", "k", &core.Value{Vi64: 2})
+	accept(r, kvs, 1, 1, 0, 1)
+	r.Nil(checker.Observe("accept 1", kvs))
+	accept(r, kvs, 2, 2, 1, 2)
+	err := checker.Observe("accept 2", kvs)
+	r.NotNil(err)
+	v := err.(*Violation)
+	r.Equal(OneValueChosen, v.Invariant)
+	r.Equal("k", v.Id.Key)
+	r.Len(v.Trace, 5)
+	r.Equal(v, checker.Err())
+
+	// a value out of nowhere is chosen
+	kvs, checker = newKVServers(), New(3)
+	accept(r, kvs, 1, 3, 0, 1)
+	err = checker.Observe("accept", kvs)
+	r.NotNil(err)
+	r.Equal(ChosenProposed, err.(*Violation).Invariant)
+	checker.Proposed("", "k", &core.Value{Vi64: 4})
+	r.Equal(ChosenProposed, checker.Decided(&core.PaxosInstanceId{Key: "x"}, &core.Value{Vi64: 4}).(*Violation).Invariant)
+
+	// an Acceptor losing its state forgets its promise
+	kvs, checker = newKVServers(), New(3)
+	accept(r, kvs, 5, 0, 0)
+	r.Nil(checker.Observe("before crash", kvs))
+	kvs[0] = core.NewKVServer()
+	accept(r, kvs, 1, 0, 0)
+	err = checker.Observe("after crash", kvs)
+	r.NotNil(err)
+	r.Equal(LastBalMonotonic, err.(*Violation).Invariant)
+	r.Equal(int64(0), err.(*Violation).Acceptor)
+}
+
+func TestChecker_Shrink(t *testing.T) {
+	r := require.New(t)
+
+	// the Acceptors promise and vote on ballots irrelevant to the violation
+	kvs, checker := newKVServers(), New(3)
+	checker.Proposed("", "k", &core.Value{Vi64: 1})
+	var changes int
+	for bal := int64(1); bal <= 3; bal++ {
+		for aid := int64(0); aid < 3; aid++ {
+			prepare(r, kvs, bal, aid)
+			r.Nil(checker.Observe(fmt.Sprintf("prepare %d on %d", bal, aid), kvs))
+			changes += 1
+		}
+	}
+	accept(r, kvs, 4, 1, 1)
+	r.Nil(checker.Observe("accept 4 on 1", kvs))
+	changes += 1
+
+	// Acceptor-0 loses its state and promises a lower ballot
+	kvs[0] = core.NewKVServer()
+	prepare(r, kvs, 2, 0)
+	err := checker.Observe("prepare 2 on 0 after crash", kvs)
+	r.NotNil(err)
+	changes += 1
+
+	v := err.(*Violation)
+	r.Equal(LastBalMonotonic, v.Invariant)
+	r.Less(len(v.Trace), changes)
+	r.Equal([]string{
+		"prepare 3 on 0: Acceptor-0 last=3.3",
+		"prepare 2 on 0 after crash: Acceptor-0 last=2.2",
+	}, v.Trace)
+}
+
+func TestChecker_Sim(t *testing.T) {
+	for seed := int64(0); seed < 100; seed++ {
+		s := sim.New(sim.Config{
+			Seed:          seed,
+			AcceptorIds:   []int64{0, 1, 2},
+			MinDelay:      time.Millisecond,
+			MaxDelay:      20 * time.Millisecond,
+			DropRate:      0.1,
+			DuplicateRate: 0.1,
+			Timeout:       50 * time.Millisecond,
+		})
+		checker := New(3)
+		s.OnEvent(func(e sim.Event) {
+			if e.Kind == sim.EventDeliver {
+				_ = checker.Observe(e.String(), s.Acceptors())
+			}
+		})
+
+		for i := int64(1); i <= 3; i++ {
+			i := i
+			s.Go(fmt.Sprintf("P%d", i), func() {
+				for ver := int64(0); ver < 2; ver++ {
+					val := &core.Value{Vi64: i*10 + ver}
+					checker.Proposed("", "k", val)
+					p := core.Proposer{Id: &core.PaxosInstanceId{Key: "k", Ver: ver}, Bal: &core.BallotNum{ProposerId: i}}
+					chosen, _ := p.ProposeWith(context.Background(), s, []int64{0, 1, 2}, val)
+					_ = checker.Decided(p.Id, chosen)
+				}
+			})
+		}
+
+		if err := s.Run(); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if err := checker.Err(); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+	}
+}
+
+func TestChecker_GRPC(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{50, 51, 52}
+	servers := core.ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	faults := core.NewFaults(1)
+	faults.Set(core.Fault{Drop: 0.05, DropReply: 0.05, Duplicate: 0.1}, acceptorIds...)
+
+	checker := New(len(acceptorIds))
+	ctx, cancel := context.WithCancel(context.Background())
+	polled := make(chan error)
+	go func() { polled <- checker.Poll(ctx, time.Millisecond, servers.KVServers()) }()
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(i)))
+			client := core.NewClient(acceptorIds, int64(i))
+			client.Transport = faults.Transport(nil)
+			for n := 0; n < 20; n++ {
+				key := fmt.Sprintf("k%d", rng.Intn(2))
+				if rng.Intn(2) == 0 {
+					_, _, _ = client.Get(key)
+					continue
+				}
+				val := &core.Value{Vi64: int64(i*100 + n)}
+				checker.Proposed("", key, val)
+				_, _ = client.Set(key, val)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	cancel()
+	r.Nil(<-polled)
+	r.Nil(checker.Observe("final", servers.KVServers()))
+}
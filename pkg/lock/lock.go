@@ -0,0 +1,156 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+var (
+	ErrLocked  = errors.New("lock is held by another owner")
+	ErrNotHeld = errors.New("lock is not held by the owner")
+)
+
+// Lock is a distributed lock with lease stored in a key of the KV.
+//
+// Every acquisition, renewal and release writes the next version of the key
+// with compare-and-set, the value of a version records the owner in `Vi64`
+// and the end of the lease in `ExpireAt`. A lock whose lease has ended is
+// released automatically.
+//
+// The fencing token of an acquisition is the version it is chosen on, it
+// increases with every acquisition of the lock.
+type Lock struct {
+	client *core.Client
+	key    string
+	owner  int64
+	lease  time.Duration
+
+	// token is the fencing token of the current acquisition, -1 if not held.
+	token int64
+}
+
+// New creates a Lock on the key for the owner, every acquisition or renewal
+// holds the lock for the lease duration.
+func New(client *core.Client, key string, owner int64, lease time.Duration) *Lock {
+	return &Lock{client: client, key: key, owner: owner, lease: lease, token: -1}
+}
+
+// Acquire tries to acquire the lock once and returns the fencing token.
+// It returns ErrLocked if the lock is held by another owner.
+func (l *Lock) Acquire() (int64, error) {
+	val, ver, err := l.client.Get(l.key)
+	if err == nil {
+		if l.held(val, ver) {
+			return l.token, nil
+		}
+		return 0, ErrLocked
+	}
+	if err != core.ErrNotFound {
+		return 0, err
+	}
+
+	if !l.client.CompareAndSet(l.key, ver+1, l.record()) {
+		return 0, ErrLocked
+	}
+
+	l.token = ver + 1
+	return l.token, nil
+}
+
+// AcquireWait tries to acquire the lock every interval until it succeeds or ctx is done.
+func (l *Lock) AcquireWait(ctx context.Context, interval time.Duration) (int64, error) {
+	for {
+		token, err := l.Acquire()
+		if err != ErrLocked {
+			return token, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Renew extends the lease of the lock held by the owner.
+// It returns ErrNotHeld if the lock has been acquired by another owner.
+func (l *Lock) Renew() error {
+	return l.update(l.record())
+}
+
+// Release releases the lock held by the owner.
+// It returns ErrNotHeld if the lock has been acquired by another owner.
+func (l *Lock) Release() error {
+	if err := l.update(&core.Value{Tombstone: true}); err != nil {
+		return err
+	}
+	l.token = -1
+	return nil
+}
+
+// Token returns the fencing token of the current acquisition, -1 if not held.
+func (l *Lock) Token() int64 {
+	return l.token
+}
+
+// update writes the next version of the lock if the latest one is still of the
+// current acquisition, even if its lease has ended.
+func (l *Lock) update(val *core.Value) error {
+	latest, ver, err := l.latest()
+	if err != nil {
+		return err
+	}
+	if !l.held(latest, ver) {
+		return ErrNotHeld
+	}
+
+	if !l.client.CompareAndSet(l.key, ver+1, val) {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// latest returns the latest chosen value of the lock regardless of expiration,
+// or nil if it is released.
+func (l *Lock) latest() (*core.Value, int64, error) {
+	val, ver, err := l.client.Get(l.key)
+	if err == core.ErrNotFound {
+		if ver < 0 {
+			return nil, ver, nil
+		}
+		// Get hides an expired value, read the version it is observed at.
+		if val = l.client.Read(l.key, ver); val != nil && val.Tombstone {
+			val = nil
+		}
+		return val, ver, nil
+	}
+	return val, ver, err
+}
+
+// held reports whether the value observed at the version belongs to the current acquisition.
+func (l *Lock) held(val *core.Value, ver int64) bool {
+	return val != nil && val.Vi64 == l.owner && l.token >= 0 && ver >= l.token
+}
+
+func (l *Lock) record() *core.Value {
+	return &core.Value{
+		Vi64:     l.owner,
+		ExpireAt: l.now().Add(l.lease).UnixNano(),
+	}
+}
+
+// now returns the current time of the clock of the Client, which the lease
+// expires by, see core.Client.Now.
+func (l *Lock) now() time.Time {
+	if l.client.Now != nil {
+		return l.client.Now()
+	}
+	return time.Now()
+}
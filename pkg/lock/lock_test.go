@@ -0,0 +1,60 @@
+package lock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestLock_AcquireRenewRelease(t *testing.T) {
+	r := require.New(t)
+
+	acceptorIds := []int64{10, 11, 12}
+	servers := core.ServeAcceptors(acceptorIds)
+	defer servers.Stop()
+
+	// leases end by the clock of the Clients
+	now := time.Now()
+	clock := func() time.Time { return now }
+	clientA, clientB := core.NewClient(acceptorIds, 1), core.NewClient(acceptorIds, 2)
+	clientA.Now, clientB.Now = clock, clock
+
+	a := New(clientA, "mutex", 1, time.Minute)
+	b := New(clientB, "mutex", 2, 100*time.Millisecond)
+
+	tokenA, err := a.Acquire()
+	r.Nil(err)
+	_, err = b.Acquire()
+	r.Equal(ErrLocked, err)
+
+	// re-entrant for the holder
+	token, err := a.Acquire()
+	r.Nil(err)
+	r.Equal(tokenA, token)
+
+	r.Nil(a.Renew())
+	r.Nil(a.Release())
+	r.Equal(int64(-1), a.Token())
+	r.Equal(ErrNotHeld, a.Renew())
+
+	tokenB, err := b.Acquire()
+	r.Nil(err)
+	r.Greater(tokenB, tokenA)
+
+	// the lease of b ends, the lock is released automatically
+	_, err = a.Acquire()
+	r.Equal(ErrLocked, err)
+	now = now.Add(150 * time.Millisecond)
+
+	tokenA, err = a.Acquire()
+	r.Nil(err)
+	r.Greater(tokenA, tokenB)
+	r.Equal(ErrNotHeld, b.Renew())
+	r.Equal(ErrNotHeld, b.Release())
+}
@@ -0,0 +1,134 @@
+package logging
+
+import (
+	"errors"
+	"sort"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// The subsystems whose levels can be changed separately at runtime.
+const (
+	// Acceptor logs the requests handled by Acceptors.
+	Acceptor = "acceptor"
+	// Proposer logs the paxos rounds run by Proposers and clients.
+	Proposer = "proposer"
+	// Transport logs connections, streams and TLS between the nodes.
+	Transport = "transport"
+	// Default logs everything else, it is the level of zap.L() and zap.S().
+	Default = "default"
+)
+
+var ErrUnknownSubsystem = errors.New("unknown log subsystem")
+
+var (
+	// levels stores the level of every subsystem.
+	levels = map[string]zap.AtomicLevel{
+		Acceptor:  zap.NewAtomicLevel(),
+		Proposer:  zap.NewAtomicLevel(),
+		Transport: zap.NewAtomicLevel(),
+		Default:   zap.NewAtomicLevel(),
+	}
+	// base stores the zapcore.Core set up, a no-op one until Setup is called.
+	base atomic.Value
+)
+
+func init() {
+	base.Store(coreHolder{zapcore.NewNopCore()})
+}
+
+// coreHolder keeps the dynamic type stored in base consistent.
+type coreHolder struct {
+	zapcore.Core
+}
+
+func baseCore() zapcore.Core {
+	return base.Load().(coreHolder).Core
+}
+
+// Logger returns the logger of the subsystem. It can be created before Setup,
+// and writes to the outputs set up by the latest Setup at the current level
+// of the subsystem.
+func Logger(subsystem string) *zap.Logger {
+	level, ok := levels[subsystem]
+	if !ok {
+		level = levels[Default]
+	}
+	logger := zap.New(&subsystemCore{level: level}, zap.AddCaller())
+	if subsystem != Default {
+		logger = logger.Named(subsystem)
+	}
+	return logger
+}
+
+// SetLevel changes the level of the subsystem at runtime.
+func SetLevel(subsystem, level string) error {
+	atomicLevel, ok := levels[subsystem]
+	if !ok {
+		return ErrUnknownSubsystem
+	}
+	l, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	atomicLevel.SetLevel(l)
+	return nil
+}
+
+// Levels returns the level of every subsystem.
+func Levels() map[string]string {
+	m := map[string]string{}
+	for subsystem, level := range levels {
+		m[subsystem] = level.String()
+	}
+	return m
+}
+
+// Subsystems returns the names of the subsystems in order.
+func Subsystems() []string {
+	var subsystems []string
+	for subsystem := range levels {
+		subsystems = append(subsystems, subsystem)
+	}
+	sort.Strings(subsystems)
+	return subsystems
+}
+
+// subsystemCore filters entries by the level of a subsystem, and delegates
+// the others to the base core.
+type subsystemCore struct {
+	level  zap.AtomicLevel
+	fields []zapcore.Field
+}
+
+func (c *subsystemCore) Enabled(l zapcore.Level) bool {
+	return c.level.Enabled(l)
+}
+
+func (c *subsystemCore) With(fields []zapcore.Field) zapcore.Core {
+	return &subsystemCore{level: c.level, fields: append(c.fields[:len(c.fields):len(c.fields)], fields...)}
+}
+
+func (c *subsystemCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+	core := baseCore()
+	if len(c.fields) > 0 {
+		core = core.With(c.fields)
+	}
+	return core.Check(ent, ce)
+}
+
+func (c *subsystemCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return baseCore().With(c.fields).Write(ent, fields)
+}
+
+func (c *subsystemCore) Sync() error {
+	return baseCore().Sync()
+}
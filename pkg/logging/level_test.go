@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestSetLevel(t *testing.T) {
+	r := require.New(t)
+
+	// loggers created before Setup write to the outputs set up later
+	acceptor := Logger(Acceptor).Sugar()
+	proposer := Logger(Proposer).Sugar()
+
+	output := filepath.Join(t.TempDir(), "app.log")
+	closeLog, err := Setup(Config{
+		Level:       "info",
+		Levels:      map[string]string{Proposer: "warn"},
+		Format:      FormatJSON,
+		OutputPaths: []string{output},
+	})
+	r.Nil(err)
+	r.Equal("info", Levels()[Acceptor])
+	r.Equal("warn", Levels()[Proposer])
+
+	acceptor.Debug("acceptor debug 1")
+	proposer.Info("proposer info 1")
+	r.Nil(SetLevel(Acceptor, "debug"))
+	r.Nil(SetLevel(Proposer, "info"))
+	acceptor.Debug("acceptor debug 2")
+	proposer.Info("proposer info 2")
+	closeLog()
+
+	data, err := os.ReadFile(output)
+	r.Nil(err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	r.Len(lines, 2)
+	r.Contains(lines[0], `"msg":"acceptor debug 2"`)
+	r.Contains(lines[0], `"logger":"acceptor"`)
+	r.Contains(lines[1], `"msg":"proposer info 2"`)
+
+	r.Equal(ErrUnknownSubsystem, SetLevel("storage", "debug"))
+	r.NotNil(SetLevel(Acceptor, "loud"))
+	_, err = Setup(Config{Levels: map[string]string{"storage": "debug"}})
+	r.Equal(ErrUnknownSubsystem, err)
+}
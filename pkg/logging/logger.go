@@ -1,35 +1,171 @@
 package logging
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/natefinch/lumberjack"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-
-	"github.com/khighness/highness-paxos-kv/pkg/toolkit"
 )
 
 // @Author Chen Zikang
 // @Email  parakovo@gmail.com
 // @Since  2022-09-08
 
-// init initializes zap.Logger then we can use zap.L() to get this logger.
-func init() {
-	var core zapcore.Core
-	fileCore := zapcore.NewCore(zapFileEncoder(), zapWriteSyncer(), zapLevelEnabler())
-	if toolkit.IsDeployLocal() {
-		consoleCore := zapcore.NewCore(zapConsoleEncoder(), os.Stdout, zapLevelEnabler())
-		core = zapcore.NewTee(fileCore, consoleCore)
-	} else {
-		core = zapcore.NewTee(fileCore)
+// The formats of log entries.
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// Config configures the global zap.Logger and the loggers of subsystems.
+// The zero value logs entries at Info level in console format to stdout.
+type Config struct {
+	// Level is the minimum level logged, e.g. "debug", "info" or "warn".
+	Level string
+	// Levels overrides Level of the subsystems, see Logger.
+	Levels map[string]string
+	// Format is FormatConsole or FormatJSON.
+	Format string
+	// OutputPaths are "stdout", "stderr" or paths of files rotated by size.
+	OutputPaths []string
+
+	// MaxSize is the size in megabytes of a log file to rotate at, 100 if 0.
+	MaxSize int
+	// MaxBackups is the number of rotated log files to retain, 0 for all.
+	MaxBackups int
+	// MaxAge is the number of days to retain rotated log files, 0 for forever.
+	MaxAge int
+	// Compress compresses the rotated log files with gzip.
+	Compress bool
+
+	// Sampling limits the entries logged with the same level and message, nil
+	// for no sampling.
+	Sampling *Sampling
+}
+
+// Sampling logs the first Initial entries with the same level and message
+// every Tick, and every Thereafter-th entry after that.
+type Sampling struct {
+	Tick       time.Duration
+	Initial    int
+	Thereafter int
+}
+
+// ErrUnknownFormat rejects a Format other than FormatConsole and FormatJSON.
+var ErrUnknownFormat = errors.New("unknown log format")
+
+// Setup replaces the global zap.Logger, which drops every entry until Setup is
+// called, so we can use zap.L() and zap.S() to log, and directs the loggers
+// of subsystems to the outputs at the levels configured. The returned function
+// flushes and closes the outputs, and restores the previous outputs and levels.
+//
+// The config is validated and the outputs are opened before anything global
+// is changed, so the loggers and levels are left as they are if Setup fails.
+func Setup(c Config) (func(), error) {
+	subsystemLevels, err := parseLevels(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var encoder zapcore.Encoder
+	switch c.Format {
+	case "", FormatConsole:
+		encoder = zapcore.NewConsoleEncoder(zapEncodeConfig())
+	case FormatJSON:
+		encoder = zapcore.NewJSONEncoder(zapEncodeConfig())
+	default:
+		return nil, ErrUnknownFormat
+	}
+
+	outputPaths := c.OutputPaths
+	if len(outputPaths) == 0 {
+		outputPaths = []string{"stdout"}
+	}
+
+	var syncers []zapcore.WriteSyncer
+	var closers []func() error
+	closeAll := func() {
+		for _, closer := range closers {
+			_ = closer()
+		}
 	}
-	logger := zap.New(core, zap.AddCaller())
-	zap.ReplaceGlobals(logger)
+	for _, path := range outputPaths {
+		switch path {
+		case "stdout":
+			syncers = append(syncers, zapcore.Lock(os.Stdout))
+		case "stderr":
+			syncers = append(syncers, zapcore.Lock(os.Stderr))
+		default:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				// Close the files of the outputs opened so far.
+				closeAll()
+				return nil, err
+			}
+			lumberJackLogger := &lumberjack.Logger{
+				Filename:   path,
+				MaxSize:    c.MaxSize,
+				MaxBackups: c.MaxBackups,
+				MaxAge:     c.MaxAge,
+				Compress:   c.Compress,
+			}
+			syncers = append(syncers, zapcore.AddSync(lumberJackLogger))
+			closers = append(closers, lumberJackLogger.Close)
+		}
+	}
+
+	// Entries are filtered by the levels of subsystems.
+	core := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(syncers...), zapcore.DebugLevel)
+	if s := c.Sampling; s != nil {
+		core = zapcore.NewSamplerWithOptions(core, s.Tick, s.Initial, s.Thereafter)
+	}
+
+	previousLevels := map[string]zapcore.Level{}
+	for subsystem, level := range subsystemLevels {
+		previousLevels[subsystem] = levels[subsystem].Level()
+		levels[subsystem].SetLevel(level)
+	}
+	previous := baseCore()
+	base.Store(coreHolder{core})
+	restore := zap.ReplaceGlobals(Logger(Default))
+	return func() {
+		_ = core.Sync()
+		restore()
+		base.Store(coreHolder{previous})
+		for subsystem, level := range previousLevels {
+			levels[subsystem].SetLevel(level)
+		}
+		closeAll()
+	}, nil
 }
 
-func zapLevelEnabler() zapcore.Level {
-	return zapcore.InfoLevel
+// parseLevels returns the level of every subsystem configured.
+func parseLevels(c Config) (map[string]zapcore.Level, error) {
+	for subsystem := range c.Levels {
+		if _, ok := levels[subsystem]; !ok {
+			return nil, ErrUnknownSubsystem
+		}
+	}
+
+	parsed := map[string]zapcore.Level{}
+	for _, subsystem := range Subsystems() {
+		l, ok := c.Levels[subsystem]
+		if !ok {
+			l = c.Level
+		}
+		if l == "" {
+			l = "info"
+		}
+		level, err := zapcore.ParseLevel(l)
+		if err != nil {
+			return nil, err
+		}
+		parsed[subsystem] = level
+	}
+	return parsed, nil
 }
 
 func zapEncodeConfig() zapcore.EncoderConfig {
@@ -50,14 +186,6 @@ func zapEncodeConfig() zapcore.EncoderConfig {
 	}
 }
 
-func zapFileEncoder() zapcore.Encoder {
-	return zapcore.NewJSONEncoder(zapEncodeConfig())
-}
-
-func zapConsoleEncoder() zapcore.Encoder {
-	return zapcore.NewConsoleEncoder(zapEncodeConfig())
-}
-
 func zapEncodeLevel(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
 	enc.AppendString("[" + level.CapitalString() + "]")
 }
@@ -65,19 +193,3 @@ func zapEncodeLevel(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
 func zapEncodeCaller(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
 	enc.AppendString(caller.TrimmedPath())
 }
-
-func zapWriteSyncer() zapcore.WriteSyncer {
-	logDir := "log/"
-	if serviceName := os.Getenv("SERVICE_NAME"); serviceName != "" {
-		logDir = "/data/log/" + serviceName
-	}
-	_ = os.Mkdir(logDir, 0777)
-	lumberJackLogger := &lumberjack.Logger{
-		Filename:   logDir + "/app.log",
-		MaxSize:    1000,
-		MaxBackups: 10,
-		MaxAge:     30,
-		Compress:   true,
-	}
-	return zapcore.AddSync(lumberJackLogger)
-}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestSetup(t *testing.T) {
+	r := require.New(t)
+
+	output := filepath.Join(t.TempDir(), "log", "app.log")
+	closeLog, err := Setup(Config{
+		Level:       "warn",
+		Format:      FormatJSON,
+		OutputPaths: []string{output},
+		Sampling:    &Sampling{Tick: time.Minute, Initial: 2, Thereafter: 100},
+	})
+	r.Nil(err)
+
+	zap.S().Info("dropped by level")
+	for i := 0; i < 5; i++ {
+		zap.S().Warn("sampled")
+	}
+	zap.S().Error("logged")
+	closeLog()
+
+	// the global logger drops every entry again
+	zap.S().Error("dropped after close")
+
+	data, err := os.ReadFile(output)
+	r.Nil(err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	r.Len(lines, 3)
+	r.Contains(lines[0], `"msg":"sampled"`)
+	r.Contains(lines[2], `"msg":"logged"`)
+}
+
+func TestSetup_InvalidLevel(t *testing.T) {
+	r := require.New(t)
+
+	levels := Levels()
+	_, err := Setup(Config{Level: "debug", Levels: map[string]string{Proposer: "loud"}})
+	r.NotNil(err)
+	_, err = Setup(Config{Level: "debug", Format: "xml"})
+	r.Equal(ErrUnknownFormat, err)
+
+	// no level is changed before the config is validated
+	r.Equal(levels, Levels())
+}
+
+func TestSetup_InvalidOutput(t *testing.T) {
+	r := require.New(t)
+
+	dir := t.TempDir()
+	r.Nil(os.WriteFile(filepath.Join(dir, "file"), nil, 0600))
+	levels := Levels()
+	_, err := Setup(Config{Level: "debug", OutputPaths: []string{
+		filepath.Join(dir, "app.log"),
+		filepath.Join(dir, "file", "app.log"),
+	}})
+	r.NotNil(err)
+
+	// the global logger and the levels are left as is
+	r.Equal(levels, Levels())
+	zap.S().Error("dropped")
+	_, err = os.Stat(filepath.Join(dir, "app.log"))
+	r.True(os.IsNotExist(err))
+}
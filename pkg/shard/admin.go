@@ -0,0 +1,305 @@
+package shard
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+var (
+	ErrMoveConflict = errors.New("target group has a different value on the version")
+	ErrFenceFailed  = errors.New("not enough acceptors of the group are fenced")
+)
+
+// Admin changes the shard map online while the ranges are serving traffic.
+type Admin struct {
+	configGroup []int64
+	proposerId  int64
+	// Transport carries the requests of the Admin to the config group and the
+	// groups of the ranges, gRPC if nil.
+	Transport core.Transport
+}
+
+// NewAdmin creates an Admin of the shard map stored on the config group.
+func NewAdmin(configGroup []int64, proposerId int64) *Admin {
+	return &Admin{configGroup: configGroup, proposerId: proposerId}
+}
+
+// Init publishes the initial shard map, it fails if a map has been published.
+func (a *Admin) Init(m *Map) error {
+	m.Version = -1
+	return Publish(a.config(), m)
+}
+
+// Map returns the latest published shard map.
+func (a *Admin) Map() (*Map, error) {
+	return Load(a.config())
+}
+
+// AddNamespace adds the namespace to the Namespaces of the map, so that the
+// keys of it are moved with the ranges.
+func (a *Admin) AddNamespace(namespace string) error {
+	m, err := Load(a.config())
+	if err != nil {
+		return err
+	}
+	for _, ns := range m.AllNamespaces() {
+		if ns == namespace {
+			return nil
+		}
+	}
+	m.Namespaces = append(m.Namespaces, namespace)
+	return Publish(a.config(), m)
+}
+
+// Split splits the range containing the routing key at the key.
+// No data is moved, both halves stay on the original group.
+func (a *Admin) Split(at string) error {
+	m, err := Load(a.config())
+	if err != nil {
+		return err
+	}
+	if err = m.Split(at); err != nil {
+		return err
+	}
+	return Publish(a.config(), m)
+}
+
+// Merge merges the range starting at the routing key into the previous range.
+// If the ranges are owned by different groups, the range is moved to the group
+// of the previous range first.
+func (a *Admin) Merge(start string) error {
+	m, err := Load(a.config())
+	if err != nil {
+		return err
+	}
+
+	i, err := m.RangeAt(start)
+	if err != nil {
+		return err
+	}
+	if i > 0 && !sameGroup(m.Ranges[i-1].Group, m.Ranges[i].Group) {
+		if err = a.Move(start, m.Ranges[i-1].Group); err != nil {
+			return err
+		}
+		if m, err = Load(a.config()); err != nil {
+			return err
+		}
+	}
+
+	if err = m.Merge(start); err != nil {
+		return err
+	}
+	return Publish(a.config(), m)
+}
+
+// Move moves the range starting at the routing key to the group.
+//
+// The range is frozen first, and a quorum of the original group is fenced at
+// the version of the frozen map, so that no write routed by an older map can
+// be chosen once the fence returns: it needs the votes of a quorum, which
+// intersects the fenced one, see core.KVServer.Fence. Then the latest version
+// of every key in it is copied to the same version on the new group, and the
+// ownership is flipped in one publication of the shard map. Finally, a quorum
+// of the original group is fenced at the version of the flipped map, so that
+// reads routed by the frozen map fail and are routed again. If fencing or
+// copying fails, the range is unfrozen on the original group.
+//
+// The fence is of the whole acceptor rather than of the moved range: from
+// then on, requests routed by an older map to any range of the original group
+// fail with core.ErrStaleEpoch, and Routers reload the map and route them
+// again, see Router. A move thus costs every client of the group one reload.
+//
+// Keys are discovered by scanning the acceptors of the original group for the
+// versions committed or voted, every chosen version has been voted by a quorum.
+// The keys of every namespace of the map are moved, see Map.Namespaces.
+func (a *Admin) Move(start string, group []int64) error {
+	m, err := Load(a.config())
+	if err != nil {
+		return err
+	}
+	i, err := m.RangeAt(start)
+	if err != nil {
+		return err
+	}
+	if sameGroup(m.Ranges[i].Group, group) {
+		return nil
+	}
+	if m.Ranges[i].Frozen {
+		return ErrRangeFrozen
+	}
+
+	m.Ranges[i].Frozen = true
+	if err = Publish(a.config(), m); err != nil {
+		return err
+	}
+
+	var moved int
+	err = a.fence(m.Ranges[i].Group, m.Version, len(m.Ranges[i].Group)/2+1)
+	if err == nil {
+		moved, err = a.copyRange(m, m.Ranges[i], group)
+	}
+	if err != nil {
+		zap.S().Errorf("Admin: failed to move range %s to %v: %v", start, group, err)
+		m.Ranges[i].Frozen = false
+		if e := Publish(a.config(), m); e != nil {
+			zap.S().Errorf("Admin: failed to unfreeze range %s: %v", start, e)
+		}
+		return err
+	}
+
+	src := m.Ranges[i].Group
+	m.Ranges[i].Group = group
+	m.Ranges[i].Frozen = false
+	if err = Publish(a.config(), m); err != nil {
+		return err
+	}
+	if err = a.fence(src, m.Version, len(src)/2+1); err != nil {
+		zap.S().Errorf("Admin: failed to fence %v after moving range %s: %v", src, start, err)
+	}
+
+	zap.S().Infof("Admin: range %s moved to %v with %d keys", start, group, moved)
+	return nil
+}
+
+// fence raises the fence of the acceptors of the group to the epoch, it fails
+// unless at least `required` acceptors acknowledge it.
+func (a *Admin) fence(group []int64, epoch int64, required int) error {
+	var acked int
+	for _, aid := range group {
+		_, err := a.transport().Fence(context.Background(), aid, &core.FenceRequest{Epoch: epoch})
+		if err != nil {
+			zap.S().Errorf("Admin: failed to fence Acceptor-%d at %d: %v", aid, epoch, err)
+			continue
+		}
+		acked += 1
+	}
+
+	if acked < required {
+		return ErrFenceFailed
+	}
+	return nil
+}
+
+// copyRange copies the latest version of every key in the range to the group,
+// in every namespace of the map, it returns the number of copied keys.
+func (a *Admin) copyRange(m *Map, r Range, group []int64) (int, error) {
+	var copied int
+	for _, ns := range m.AllNamespaces() {
+		n, err := a.copyNamespace(m, r, group, ns)
+		copied += n
+		if err != nil {
+			return copied, err
+		}
+	}
+	return copied, nil
+}
+
+// copyNamespace copies the latest version of every key in the range of the
+// namespace to the group, it returns the number of copied keys.
+func (a *Admin) copyNamespace(m *Map, r Range, group []int64, namespace string) (int, error) {
+	src := a.client(r.Group, m.Version, namespace)
+	dst := a.client(group, m.Version, namespace)
+
+	keys, err := a.rangeKeys(m, r, namespace)
+	if err != nil {
+		return 0, err
+	}
+
+	var copied int
+	for key := range keys {
+		val, ver, err := src.Get(key)
+		if err == core.ErrNotFound {
+			if ver < 0 {
+				continue
+			}
+			// Keep the tombstone, so that versions of the key never go backwards.
+			val = &core.Value{Tombstone: true}
+		} else if err != nil {
+			return copied, err
+		} else {
+			val = withoutTxn(val)
+		}
+
+		// The group may have the version already, e.g. moved back unchanged.
+		if !dst.CompareAndSet(key, ver, val) && !proto.Equal(withoutTxn(dst.Read(key, ver)), val) {
+			return copied, ErrMoveConflict
+		}
+		copied += 1
+	}
+	return copied, nil
+}
+
+// withoutTxn returns a copy of the value in effect without its transaction,
+// whose record is not moved. It returns nil for nil.
+func withoutTxn(val *core.Value) *core.Value {
+	if val == nil {
+		return nil
+	}
+	val = proto.Clone(val).(*core.Value)
+	val.TxnId = ""
+	return val
+}
+
+// rangeKeys returns the keys in the range of the namespace committed or voted
+// on any acceptor of its group.
+func (a *Admin) rangeKeys(m *Map, r Range, namespace string) (map[string]bool, error) {
+	req := &core.ScanRequest{Tombstones: true, Voted: true, Namespace: namespace}
+	if m.Mode == ModeRange {
+		req.StartKey, req.EndKey = r.Start, r.End
+	}
+
+	keys := map[string]bool{}
+	var scanned int
+	for _, aid := range r.Group {
+		req.PageToken = ""
+		for {
+			reply, err := a.transport().Scan(context.Background(), aid, req)
+			if err != nil {
+				zap.S().Errorf("Admin: failed to scan Acceptor-%d: %v", aid, err)
+				break
+			}
+			for _, inst := range reply.Instances {
+				if r.Contains(m.RoutingKey(inst.Id.Key)) {
+					keys[inst.Id.Key] = true
+				}
+			}
+			if req.PageToken = reply.NextPageToken; req.PageToken == "" {
+				scanned += 1
+				break
+			}
+		}
+	}
+
+	if scanned < len(r.Group)/2+1 {
+		return nil, core.ErrNoEnoughQuorum
+	}
+	return keys, nil
+}
+
+// config returns a Client of the config group.
+func (a *Admin) config() *core.Client {
+	return a.client(a.configGroup, 0, "")
+}
+
+// client returns a Client of the group in the namespace, routing by the map
+// of the epoch.
+func (a *Admin) client(group []int64, epoch int64, namespace string) *core.Client {
+	c := core.NewClient(group, a.proposerId)
+	c.Epoch = epoch
+	c.Namespace = namespace
+	c.Transport = a.Transport
+	return c
+}
+
+// transport returns the Transport of the Admin.
+func (a *Admin) transport() core.Transport {
+	return core.OrGRPC(a.Transport)
+}
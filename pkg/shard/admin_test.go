@@ -0,0 +1,172 @@
+package shard
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestAdmin_SplitMoveMerge(t *testing.T) {
+	r := require.New(t)
+
+	configGroup := []int64{30, 31, 32}
+	groupA := []int64{33, 34, 35}
+	groupB := []int64{36, 37, 38}
+	servers := ServeGroups(configGroup, groupA, groupB)
+	defer servers.Stop()
+
+	admin := NewAdmin(configGroup, 1)
+	m, err := NewRangeMap(nil, [][]int64{groupA})
+	r.Nil(err)
+	r.Nil(admin.Init(m))
+
+	router := NewRouter(configGroup, 2)
+	for _, key := range []string{"apple", "banana", "melon", "zebra"} {
+		_, err = router.Set(key, &core.Value{Vi64: 1})
+		r.Nil(err)
+	}
+	deleted, err := router.Delete("melon")
+	r.Nil(err)
+
+	// a key written by a transaction, and a key voted by a quorum but never committed
+	c, err := router.Client("yak")
+	r.Nil(err)
+	txn := c.Begin()
+	r.Nil(txn.Set("yak", &core.Value{Vi64: 7}))
+	r.Nil(txn.Commit())
+	p := &core.Proposer{
+		Id:    &core.PaxosInstanceId{Key: "xenon", Ver: 0},
+		Bal:   &core.BallotNum{N: 1, ProposerId: 4},
+		Val:   &core.Value{Vi64: 9},
+		Epoch: c.Epoch,
+	}
+	_, _, err = p.Phase1(groupA[:2], 2)
+	r.Nil(err)
+	_, err = p.Phase2(groupA[:2], 2)
+	r.Nil(err)
+
+	r.Nil(admin.Split("m"))
+
+	// keep writing to the range while it is being moved
+	var wg sync.WaitGroup
+	var last int64
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		writer := NewRouter(configGroup, 3)
+		for i := int64(2); i <= 20; i++ {
+			if _, err := writer.Set("zebra", &core.Value{Vi64: i}); err == nil {
+				last = i
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	r.Nil(admin.Move("m", groupB))
+	wg.Wait()
+	r.Equal(int64(20), last)
+
+	m, err = admin.Map()
+	r.Nil(err)
+	r.Len(m.Ranges, 2)
+	r.Equal(groupB, m.Ranges[1].Group)
+	r.False(m.Ranges[1].Frozen)
+
+	val, _, err := router.Get("zebra")
+	r.Nil(err)
+	r.Equal(int64(20), val.Vi64)
+
+	val, _, err = router.Get("yak")
+	r.Nil(err)
+	r.Equal(int64(7), val.Vi64)
+	val, _, err = router.Get("xenon")
+	r.Nil(err)
+	r.Equal(int64(9), val.Vi64)
+
+	// the tombstone is moved with its version
+	_, ver, err := router.Get("melon")
+	r.Equal(core.ErrNotFound, err)
+	r.Equal(deleted, ver)
+
+	// the ranges are owned by different groups, merging moves the data back
+	r.Nil(admin.Merge("m"))
+	m, err = admin.Map()
+	r.Nil(err)
+	r.Len(m.Ranges, 1)
+	r.Equal(groupA, m.Ranges[0].Group)
+
+	val, _, err = router.Get("zebra")
+	r.Nil(err)
+	r.Equal(int64(20), val.Vi64)
+	val, _, err = router.Get("apple")
+	r.Nil(err)
+	r.Equal(int64(1), val.Vi64)
+	val, _, err = router.Get("yak")
+	r.Nil(err)
+	r.Equal(int64(7), val.Vi64)
+}
+
+func TestAdmin_MoveNamespacesWithQuorum(t *testing.T) {
+	r := require.New(t)
+
+	configGroup := []int64{60, 61, 62}
+	groupA := []int64{63, 64, 65}
+	groupB := []int64{66, 67, 68}
+	servers := ServeGroups(configGroup, groupA, groupB)
+	defer servers.Stop()
+
+	faults := core.NewFaults(1)
+	admin := NewAdmin(configGroup, 1)
+	admin.Transport = faults.Transport(nil)
+	m, err := NewRangeMap([]string{"m"}, [][]int64{groupA, groupA})
+	r.Nil(err)
+	r.Nil(admin.Init(m))
+	r.Nil(admin.AddNamespace("tenant"))
+	m, err = admin.Map()
+	r.Nil(err)
+
+	// keys of both namespaces in both ranges
+	for _, ns := range m.AllNamespaces() {
+		c := core.NewClient(groupA, 2)
+		c.Namespace, c.Epoch = ns, m.Version
+		for _, key := range []string{"apple", "zebra"} {
+			_, err = c.Set(key, &core.Value{Vi64: 1})
+			r.Nil(err)
+		}
+	}
+	stale := core.NewClient(groupA, 3)
+	stale.Epoch = m.Version
+	router := NewRouter(configGroup, 4)
+	_, err = router.Map()
+	r.Nil(err)
+
+	// fencing and copying need a quorum of the original group only
+	faults.Partition(groupA[0])
+	r.Nil(admin.Move("m", groupB))
+	faults.Heal()
+
+	m, err = admin.Map()
+	r.Nil(err)
+	for _, ns := range m.AllNamespaces() {
+		c := core.NewClient(groupB, 2)
+		c.Namespace, c.Epoch = ns, m.Version
+		val, _, err := c.Get("zebra")
+		r.Nil(err)
+		r.Equal(int64(1), val.Vi64)
+		_, _, err = c.Get("apple")
+		r.Equal(core.ErrNotFound, err)
+	}
+
+	// the fence is of the whole group, the range not moved is fenced as well
+	_, err = stale.Set("apple", &core.Value{Vi64: 2})
+	r.Equal(core.ErrStaleEpoch, err)
+	_, err = router.Set("apple", &core.Value{Vi64: 2})
+	r.Nil(err)
+}
@@ -0,0 +1,193 @@
+package shard
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+var (
+	// FrozenRetryInterval is the interval a write to a frozen range is retried.
+	FrozenRetryInterval = 50 * time.Millisecond
+	// FrozenTimeout is the maximum time a write waits for a frozen range.
+	FrozenTimeout = 10 * time.Second
+)
+
+// Router routes the operations on keys to the acceptor groups owning them,
+// according to the shard map it caches from the config group.
+//
+// The clients of a Router carry the version of the cached map as their epoch,
+// see core.Client.Epoch. Once an Admin moves a range away from a group, the
+// group fences out the older epochs, and an operation routed by a stale map
+// fails with core.ErrStaleEpoch, then the Router reloads the map and routes
+// the operation again. Writes to a frozen range wait until it is unfrozen, by
+// then the range may be owned by another group.
+type Router struct {
+	config     *core.Client
+	proposerId int64
+
+	mu sync.RWMutex
+	m  *Map
+	// clients stores the Client of every group routed to by m.
+	clients map[string]*core.Client
+}
+
+// NewRouter creates a Router loading the shard map from the config group.
+func NewRouter(configGroup []int64, proposerId int64) *Router {
+	return &Router{
+		config:     core.NewClient(configGroup, proposerId),
+		proposerId: proposerId,
+		clients:    map[string]*core.Client{},
+	}
+}
+
+// Refresh reloads the latest shard map from the config group.
+func (r *Router) Refresh() error {
+	m, err := Load(r.config)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.m == nil || m.Version > r.m.Version {
+		r.m = m
+		r.clients = map[string]*core.Client{}
+	}
+	return nil
+}
+
+// Map returns the cached shard map, loading it if not cached yet.
+func (r *Router) Map() (*Map, error) {
+	r.mu.RLock()
+	m := r.m
+	r.mu.RUnlock()
+
+	if m != nil {
+		return m, nil
+	}
+	if err := r.Refresh(); err != nil {
+		return nil, err
+	}
+	return r.Map()
+}
+
+// Client returns the Client of the acceptor group owning the key to write
+// with. Its operations fail with core.ErrStaleEpoch, or CompareAndSet returns
+// false, once the range is moved away, Refresh and get the Client again then.
+func (r *Router) Client(key string) (*core.Client, error) {
+	return r.route(key, true)
+}
+
+// Get reads the key from the group owning it, see core.Client.Get.
+func (r *Router) Get(key string) (val *core.Value, ver int64, err error) {
+	err = r.do(key, false, func(c *core.Client) error {
+		val, ver, err = c.Get(key)
+		return err
+	})
+	return val, ver, err
+}
+
+// Set writes the key to the group owning it, see core.Client.Set.
+func (r *Router) Set(key string, val *core.Value) (ver int64, err error) {
+	err = r.do(key, true, func(c *core.Client) error {
+		ver, err = c.Set(key, val)
+		return err
+	})
+	return ver, err
+}
+
+// Delete deletes the key from the group owning it, see core.Client.Delete.
+func (r *Router) Delete(key string) (ver int64, err error) {
+	err = r.do(key, true, func(c *core.Client) error {
+		ver, err = c.Delete(key)
+		return err
+	})
+	return ver, err
+}
+
+// do runs the operation with the Client of the group owning the key, and
+// runs it again by the reloaded map if the cached one is stale.
+func (r *Router) do(key string, write bool, op func(*core.Client) error) error {
+	for {
+		c, err := r.route(key, write)
+		if err != nil {
+			return err
+		}
+		if err = op(c); err != core.ErrStaleEpoch {
+			return err
+		}
+		if err = r.Refresh(); err != nil {
+			return err
+		}
+	}
+}
+
+// route returns the Client of the group owning the key in the cached map.
+// For a write, it waits until the range owning the key is not frozen,
+// reloading the map meanwhile.
+func (r *Router) route(key string, write bool) (*core.Client, error) {
+	deadline := time.Now().Add(FrozenTimeout)
+	for {
+		m, err := r.Map()
+		if err != nil {
+			return nil, err
+		}
+
+		rg := m.Ranges[m.Locate(key)]
+		if !write || !rg.Frozen {
+			return r.groupClient(m, rg.Group), nil
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrRangeFrozen
+		}
+		time.Sleep(FrozenRetryInterval)
+		if err = r.Refresh(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// groupClient returns the Client of the group routing by the map.
+func (r *Router) groupClient(m *Map, group []int64) *core.Client {
+	id := fmt.Sprint(group)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m != r.m {
+		// The map has been reloaded meanwhile, do not cache a Client of a stale epoch.
+		c := core.NewClient(group, r.proposerId)
+		c.Epoch = m.Version
+		return c
+	}
+	c, ok := r.clients[id]
+	if !ok {
+		c = core.NewClient(group, r.proposerId)
+		c.Epoch = m.Version
+		r.clients[id] = c
+	}
+	return c
+}
+
+// ServeGroups starts the acceptors of many groups in this process, every
+// acceptor is served once even if it is in more than one group.
+func ServeGroups(groups ...[]int64) *core.Acceptors {
+	var acceptorIds []int64
+	seen := map[int64]bool{}
+	for _, group := range groups {
+		for _, aid := range group {
+			if !seen[aid] {
+				seen[aid] = true
+				acceptorIds = append(acceptorIds, aid)
+			}
+		}
+	}
+	return core.ServeAcceptors(acceptorIds)
+}
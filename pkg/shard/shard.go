@@ -0,0 +1,238 @@
+package shard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// MapKey is the key the shard map is stored in, on the config group.
+const MapKey = "__shardmap"
+
+// The modes to route keys by.
+const (
+	// ModeRange routes a key by the key itself.
+	ModeRange = "range"
+	// ModeHash routes a key by the hex encoded FNV-1a hash of the key.
+	ModeHash = "hash"
+)
+
+var (
+	ErrInvalidMap  = errors.New("invalid shard map")
+	ErrNoMap       = errors.New("no shard map published")
+	ErrStaleMap    = errors.New("shard map has been changed by others")
+	ErrNoRange     = errors.New("no range starts at the key")
+	ErrRangeFrozen = errors.New("range is frozen")
+)
+
+// Range is a range of routing keys [Start, End) owned by an acceptor group.
+// An empty End means no upper bound.
+//
+// A frozen range is being moved to another group, it serves reads but writes
+// to it wait until it is unfrozen.
+type Range struct {
+	Start  string  `json:"start"`
+	End    string  `json:"end"`
+	Group  []int64 `json:"group"`
+	Frozen bool    `json:"frozen,omitempty"`
+}
+
+// Contains reports whether the routing key is in the range.
+func (r *Range) Contains(rk string) bool {
+	return rk >= r.Start && (r.End == "" || rk < r.End)
+}
+
+// Map is the shard map routing every key to the acceptor group of a range.
+// The ranges are sorted and cover the whole routing key space.
+type Map struct {
+	// Version is the version of MapKey the map is chosen on, -1 if unpublished.
+	Version int64   `json:"-"`
+	Mode    string  `json:"mode"`
+	Ranges  []Range `json:"ranges"`
+	// Namespaces are the namespaces other than the default one the groups hold
+	// records of, a moved range carries the keys of all of them.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// NewRangeMap creates a map routing keys by range, splitting the key space at
+// the split keys into len(splits)+1 ranges owned by the groups in order.
+func NewRangeMap(splits []string, groups [][]int64) (*Map, error) {
+	if len(groups) != len(splits)+1 {
+		return nil, ErrInvalidMap
+	}
+
+	m := &Map{Version: -1, Mode: ModeRange}
+	bounds := append(append([]string{""}, splits...), "")
+	for i, group := range groups {
+		m.Ranges = append(m.Ranges, Range{Start: bounds[i], End: bounds[i+1], Group: group})
+	}
+	return m, m.Validate()
+}
+
+// NewHashMap creates a map routing keys by hash, splitting the hash space
+// evenly among the groups.
+func NewHashMap(groups [][]int64) (*Map, error) {
+	if len(groups) == 0 {
+		return nil, ErrInvalidMap
+	}
+
+	var splits []string
+	step := ^uint64(0) / uint64(len(groups))
+	for i := 1; i < len(groups); i++ {
+		splits = append(splits, fmt.Sprintf("%016x", step*uint64(i)))
+	}
+
+	m, err := NewRangeMap(splits, groups)
+	if err != nil {
+		return nil, err
+	}
+	m.Mode = ModeHash
+	return m, nil
+}
+
+// Validate checks the ranges are sorted, contiguous and cover the whole space.
+func (m *Map) Validate() error {
+	if m.Mode != ModeRange && m.Mode != ModeHash || len(m.Ranges) == 0 {
+		return ErrInvalidMap
+	}
+	if m.Ranges[0].Start != "" || m.Ranges[len(m.Ranges)-1].End != "" {
+		return ErrInvalidMap
+	}
+	for i, r := range m.Ranges {
+		if len(r.Group) == 0 || r.End != "" && r.Start >= r.End {
+			return ErrInvalidMap
+		}
+		if i > 0 && m.Ranges[i-1].End != r.Start {
+			return ErrInvalidMap
+		}
+	}
+	return nil
+}
+
+// RoutingKey returns the key the map routes the record key by.
+func (m *Map) RoutingKey(key string) string {
+	if m.Mode == ModeHash {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		return fmt.Sprintf("%016x", h.Sum64())
+	}
+	return key
+}
+
+// Locate returns the index of the range owning the key.
+func (m *Map) Locate(key string) int {
+	rk := m.RoutingKey(key)
+	return sort.Search(len(m.Ranges), func(i int) bool {
+		return m.Ranges[i].End == "" || rk < m.Ranges[i].End
+	})
+}
+
+// RangeAt returns the index of the range starting at the routing key.
+func (m *Map) RangeAt(start string) (int, error) {
+	for i, r := range m.Ranges {
+		if r.Start == start {
+			return i, nil
+		}
+	}
+	return 0, ErrNoRange
+}
+
+// Split splits the range containing the routing key into two ranges at the key,
+// both owned by the original group.
+func (m *Map) Split(at string) error {
+	i := sort.Search(len(m.Ranges), func(i int) bool {
+		return m.Ranges[i].End == "" || at < m.Ranges[i].End
+	})
+	if i == len(m.Ranges) || m.Ranges[i].Start == at {
+		return ErrInvalidMap
+	}
+
+	left, right := m.Ranges[i], m.Ranges[i]
+	left.End, right.Start = at, at
+	m.Ranges = append(m.Ranges[:i], append([]Range{left, right}, m.Ranges[i+1:]...)...)
+	return m.Validate()
+}
+
+// Merge merges the range starting at the routing key into the previous range.
+// Both ranges must be owned by the same group and not frozen.
+func (m *Map) Merge(start string) error {
+	i, err := m.RangeAt(start)
+	if err != nil {
+		return err
+	}
+	if i == 0 || !sameGroup(m.Ranges[i-1].Group, m.Ranges[i].Group) ||
+		m.Ranges[i-1].Frozen || m.Ranges[i].Frozen {
+		return ErrInvalidMap
+	}
+
+	m.Ranges[i-1].End = m.Ranges[i].End
+	m.Ranges = append(m.Ranges[:i], m.Ranges[i+1:]...)
+	return m.Validate()
+}
+
+func sameGroup(a, b []int64) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// AllNamespaces returns the default namespace and the Namespaces of the map.
+func (m *Map) AllNamespaces() []string {
+	return append([]string{""}, m.Namespaces...)
+}
+
+// Groups returns all the distinct acceptor groups in the map.
+func (m *Map) Groups() [][]int64 {
+	var groups [][]int64
+	seen := map[string]bool{}
+	for _, r := range m.Ranges {
+		if id := fmt.Sprint(r.Group); !seen[id] {
+			seen[id] = true
+			groups = append(groups, r.Group)
+		}
+	}
+	return groups
+}
+
+// Load reads the latest published shard map from the config group.
+func Load(config *core.Client) (*Map, error) {
+	val, ver, err := config.Get(MapKey)
+	if err == core.ErrNotFound {
+		return nil, ErrNoMap
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Map{}
+	if err = json.Unmarshal(val.Data, m); err != nil {
+		return nil, err
+	}
+	m.Version = ver
+	return m, nil
+}
+
+// Publish writes the map as the version following m.Version on the config
+// group, and updates m.Version. It returns ErrStaleMap if another map has
+// been published since m was loaded.
+func Publish(config *core.Client, m *Map) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	if !config.CompareAndSet(MapKey, m.Version+1, &core.Value{Data: data}) {
+		return ErrStaleMap
+	}
+	m.Version += 1
+	return nil
+}
@@ -0,0 +1,76 @@
+package shard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestMap_Locate(t *testing.T) {
+	r := require.New(t)
+
+	m, err := NewRangeMap([]string{"h", "p"}, [][]int64{{0}, {1}, {2}})
+	r.Nil(err)
+	r.Equal(0, m.Locate("apple"))
+	r.Equal(1, m.Locate("h"))
+	r.Equal(1, m.Locate("orange"))
+	r.Equal(2, m.Locate("pear"))
+
+	m, err = NewHashMap([][]int64{{0}, {1}, {2}, {3}})
+	r.Nil(err)
+	r.Len(m.Ranges, 4)
+	hits := map[int]int{}
+	for i := 0; i < 1000; i++ {
+		hits[m.Locate(string(rune('a'+i%26))+string(rune(i)))] += 1
+	}
+	r.Len(hits, 4)
+
+	_, err = NewRangeMap([]string{"p", "h"}, [][]int64{{0}, {1}, {2}})
+	r.Equal(ErrInvalidMap, err)
+}
+
+func TestRouter_RouteToGroups(t *testing.T) {
+	r := require.New(t)
+
+	configGroup := []int64{20, 21, 22}
+	groupA := []int64{23, 24, 25}
+	groupB := []int64{26, 27, 28}
+	servers := ServeGroups(configGroup, groupA, groupB)
+	defer servers.Stop()
+
+	config := core.NewClient(configGroup, 1)
+	m, err := NewRangeMap([]string{"m"}, [][]int64{groupA, groupB})
+	r.Nil(err)
+	r.Nil(Publish(config, m))
+	r.Equal(int64(0), m.Version)
+
+	// a map based on a stale version is rejected
+	stale, _ := NewRangeMap(nil, [][]int64{groupA})
+	r.Equal(ErrStaleMap, Publish(config, stale))
+
+	router := NewRouter(configGroup, 2)
+	_, err = router.Set("apple", &core.Value{Vi64: 1})
+	r.Nil(err)
+	_, err = router.Set("zebra", &core.Value{Vi64: 2})
+	r.Nil(err)
+
+	val, _, err := router.Get("zebra")
+	r.Nil(err)
+	r.Equal(int64(2), val.Vi64)
+
+	// every key is stored only in the group owning it
+	reply, err := core.NewClient(groupA, 1).Scan(groupA[0], "", "", 0, "")
+	r.Nil(err)
+	r.Len(reply.Instances, 1)
+	r.Equal("apple", reply.Instances[0].Id.Key)
+
+	reply, err = core.NewClient(groupB, 1).Scan(groupB[0], "", "", 0, "")
+	r.Nil(err)
+	r.Len(reply.Instances, 1)
+	r.Equal("zebra", reply.Instances[0].Id.Key)
+}
@@ -0,0 +1,83 @@
+package sim
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// The kinds of events.
+const (
+	// EventStart starts a task.
+	EventStart = "start"
+	// EventWake wakes up a sleeping task.
+	EventWake = "wake"
+	// EventExit ends a task.
+	EventExit = "exit"
+	// EventSend sends a request of a task.
+	EventSend = "send"
+	// EventDrop loses a request or a reply.
+	EventDrop = "drop"
+	// EventDeliver delivers a request to an Acceptor.
+	EventDeliver = "deliver"
+	// EventReply delivers a reply to a task.
+	EventReply = "reply"
+	// EventTimeout fails a request no reply is delivered for in time.
+	EventTimeout = "timeout"
+)
+
+// Event is an event run by the simulation.
+type Event struct {
+	// At is the simulated time the event runs at.
+	At   time.Duration
+	Kind string
+	Task string
+	// Call identifies the request of a task.
+	Call     int64
+	Acceptor int64
+	Method   string
+	// Request and Reply are set for paxos requests, Prepare, Accept and Commit.
+	Request *core.Proposer
+	Reply   *core.Acceptor
+	Err     error
+}
+
+func (e Event) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%10v %-8s %s", e.At, e.Kind, e.Task)
+	if e.Call == 0 {
+		return b.String()
+	}
+
+	fmt.Fprintf(b, " #%d %s A%d", e.Call, e.Method, e.Acceptor)
+	if r := e.Request; r != nil {
+		fmt.Fprintf(b, " %s@%d bal=%d.%d", r.Id.GetKey(), r.Id.GetVer(), r.Bal.GetN(), r.Bal.GetProposerId())
+		if r.Val != nil {
+			fmt.Fprintf(b, " val=%d", r.Val.Vi64)
+		}
+	}
+	if r := e.Reply; r != nil {
+		fmt.Fprintf(b, " last=%d.%d", r.LastBal.GetN(), r.LastBal.GetProposerId())
+		if r.Val != nil {
+			fmt.Fprintf(b, " voted=%d@%d.%d", r.Val.Vi64, r.VBal.GetN(), r.VBal.GetProposerId())
+		}
+	}
+	if e.Err != nil {
+		fmt.Fprintf(b, " err=%v", e.Err)
+	}
+	return b.String()
+}
+
+// FormatTrace formats the events one per line.
+func FormatTrace(events []Event) string {
+	lines := make([]string, len(events))
+	for i, e := range events {
+		lines[i] = e.String()
+	}
+	return strings.Join(lines, "\n")
+}
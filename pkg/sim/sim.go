@@ -0,0 +1,398 @@
+package sim
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// Epoch is the wall time the simulated clock starts at, the clock of the
+// Clients of a simulation reads Epoch plus Now.
+var Epoch = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	ErrStepLimit = errors.New("sim: step limit reached, livelock")
+	ErrTimeout   = status.Error(codes.DeadlineExceeded, "sim: request timed out")
+)
+
+// Config configures a simulation. A zero fault rate disables the fault.
+type Config struct {
+	// Seed determines every choice of the simulation, a run is replayed exactly
+	// by running it again with the same seed.
+	Seed int64
+	// AcceptorIds are the ids of the Acceptors simulated.
+	AcceptorIds []int64
+	// ServerOptions configure the Acceptors.
+	ServerOptions []core.ServerOption
+
+	// MinDelay and MaxDelay bound the latency of a message, which is chosen
+	// uniformly, so that messages are reordered.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// DropRate is the probability a request or a reply is lost.
+	DropRate float64
+	// DuplicateRate is the probability a request is delivered twice.
+	DuplicateRate float64
+	// Timeout is the time a Proposer waits for a reply, 1s if 0.
+	Timeout time.Duration
+
+	// MaxSteps bounds the events run, 100000 if 0, so that a livelock fails
+	// the run rather than hangs.
+	MaxSteps int
+}
+
+// Sim runs Proposers and Clients against in-process Acceptors over a
+// simulated network and clock, deterministically from a seed.
+//
+// Proposers and Clients run in tasks started by Go, with the Sim as their
+// Transport. Only one task runs at a time, until it sends a request or
+// sleeps, then the simulation runs the next event in the order of the
+// simulated time. Messages are delivered to the Acceptors in the simulation,
+// and the replies to the tasks, with the latency, losses and duplicates
+// chosen by the seed.
+type Sim struct {
+	config Config
+	rng    *rand.Rand
+
+	now   time.Duration
+	seq   int64
+	steps int
+	queue eventQueue
+	trace []Event
+	// observers are called with every event run.
+	observers []func(Event)
+
+	acceptors map[int64]*core.KVServer
+
+	// current is the running task, yield is signaled when it blocks or ends.
+	current *task
+	yield   chan struct{}
+}
+
+// task is a goroutine scheduled by the simulation.
+type task struct {
+	name string
+	wake chan result
+}
+
+// call is a request sent by a task, resolved by its first reply or timeout.
+type call struct {
+	id     int64
+	task   *task
+	aid    int64
+	method string
+	req    proto.Message
+	done   bool
+}
+
+type result struct {
+	reply proto.Message
+	err   error
+}
+
+// New creates a simulation with empty Acceptors.
+func New(c Config) *Sim {
+	if c.Timeout == 0 {
+		c.Timeout = time.Second
+	}
+	if c.MaxSteps == 0 {
+		c.MaxSteps = 100000
+	}
+
+	s := &Sim{
+		config:    c,
+		rng:       rand.New(rand.NewSource(c.Seed)),
+		acceptors: map[int64]*core.KVServer{},
+		yield:     make(chan struct{}),
+	}
+	for _, aid := range c.AcceptorIds {
+		s.acceptors[aid] = core.NewKVServer(c.ServerOptions...)
+	}
+	return s
+}
+
+// Acceptor returns the Acceptor with the id, nil if it is not simulated.
+func (s *Sim) Acceptor(aid int64) *core.KVServer {
+	return s.acceptors[aid]
+}
+
+// Acceptors returns the Acceptors simulated, by id.
+func (s *Sim) Acceptors() map[int64]*core.KVServer {
+	return s.acceptors
+}
+
+// OnEvent calls fn with every event once it has run, e.g. to check the states
+// of the Acceptors after every request delivered. fn must not block.
+func (s *Sim) OnEvent(fn func(Event)) {
+	s.observers = append(s.observers, fn)
+}
+
+// Now returns the simulated time since the simulation started.
+func (s *Sim) Now() time.Duration {
+	return s.now
+}
+
+// Trace returns the events run so far.
+func (s *Sim) Trace() []Event {
+	return s.trace
+}
+
+// Go starts a task running fn, e.g. a Proposer, at the current simulated time.
+// It can be called before Run or by a running task.
+func (s *Sim) Go(name string, fn func()) {
+	t := &task{name: name, wake: make(chan result)}
+	go func() {
+		<-t.wake
+		fn()
+		s.record(Event{Kind: EventExit, Task: t.name})
+		s.yield <- struct{}{}
+	}()
+	s.schedule(s.now, &event{kind: EventStart, task: t})
+}
+
+// Sleep blocks the running task for d of simulated time.
+func (s *Sim) Sleep(d time.Duration) {
+	t := s.current
+	s.schedule(s.now+d, &event{kind: EventWake, task: t})
+	s.block(t)
+}
+
+// Run runs the events until every task ends.
+// It returns ErrStepLimit if the tasks do not end within MaxSteps events,
+// the tasks left are blocked forever.
+func (s *Sim) Run() error {
+	for s.queue.Len() > 0 {
+		if s.steps >= s.config.MaxSteps {
+			return fmt.Errorf("%w: seed %d, %d steps", ErrStepLimit, s.config.Seed, s.steps)
+		}
+		s.steps += 1
+
+		e := heap.Pop(&s.queue).(*event)
+		s.now = e.at
+		s.run(e)
+	}
+	return nil
+}
+
+// Client returns a Client of the Acceptors sending requests through the
+// simulation and expiring values by the simulated clock, it must run in a
+// task started by Go.
+func (s *Sim) Client(proposerId int64) *core.Client {
+	c := core.NewClient(s.config.AcceptorIds, proposerId)
+	c.Transport = s
+	c.Now = func() time.Time { return Epoch.Add(s.now) }
+	return c
+}
+
+// Call sends a paxos request of the running task, it implements core.Transport.
+func (s *Sim) Call(_ context.Context, aid int64, method string, req *core.Proposer) (*core.Acceptor, error) {
+	reply, err := s.send(aid, method, req)
+	if err != nil {
+		return nil, err
+	}
+	return reply.(*core.Acceptor), nil
+}
+
+// LatestVersion sends a LatestVersion request of the running task, it
+// implements core.Transport.
+func (s *Sim) LatestVersion(_ context.Context, aid int64, req *core.LatestVersionRequest) (*core.LatestVersionReply, error) {
+	reply, err := s.send(aid, "LatestVersion", req)
+	if err != nil {
+		return nil, err
+	}
+	return reply.(*core.LatestVersionReply), nil
+}
+
+// Scan sends a Scan request of the running task, it implements core.Transport.
+func (s *Sim) Scan(_ context.Context, aid int64, req *core.ScanRequest) (*core.ScanReply, error) {
+	reply, err := s.send(aid, "Scan", req)
+	if err != nil {
+		return nil, err
+	}
+	return reply.(*core.ScanReply), nil
+}
+
+// Fence sends a Fence request of the running task, it implements core.Transport.
+func (s *Sim) Fence(_ context.Context, aid int64, req *core.FenceRequest) (*core.FenceReply, error) {
+	reply, err := s.send(aid, "Fence", req)
+	if err != nil {
+		return nil, err
+	}
+	return reply.(*core.FenceReply), nil
+}
+
+// send sends a request of the running task and blocks it until the reply or
+// the timeout. Requests must be sent by tasks started by Go.
+func (s *Sim) send(aid int64, method string, req proto.Message) (proto.Message, error) {
+	t := s.current
+	s.seq += 1
+	c := &call{id: s.seq, task: t, aid: aid, method: method, req: proto.Clone(req)}
+	s.record(Event{Kind: EventSend, Task: t.name, Call: c.id, Acceptor: aid, Method: method, Request: paxosRequest(c.req)})
+
+	copies := 1
+	if s.rng.Float64() < s.config.DuplicateRate {
+		copies = 2
+	}
+	for i := 0; i < copies; i++ {
+		// requests to Acceptors not simulated are lost
+		if _, ok := s.acceptors[aid]; !ok || s.rng.Float64() < s.config.DropRate {
+			s.record(Event{Kind: EventDrop, Task: t.name, Call: c.id, Acceptor: aid, Method: method})
+			continue
+		}
+		s.schedule(s.now+s.delay(), &event{kind: EventDeliver, call: c})
+	}
+	s.schedule(s.now+s.config.Timeout, &event{kind: EventTimeout, call: c})
+
+	res := s.block(t)
+	return res.reply, res.err
+}
+
+// run runs an event, resuming the task it wakes up.
+func (s *Sim) run(e *event) {
+	switch e.kind {
+	case EventStart, EventWake:
+		s.record(Event{Kind: e.kind, Task: e.task.name})
+		s.resume(e.task, result{})
+	case EventDeliver:
+		c := e.call
+		reply, err := s.handle(c)
+		s.record(Event{Kind: EventDeliver, Task: c.task.name, Call: c.id, Acceptor: c.aid, Method: c.method, Reply: paxosReply(reply), Err: err})
+		if s.rng.Float64() < s.config.DropRate {
+			s.record(Event{Kind: EventDrop, Task: c.task.name, Call: c.id, Acceptor: c.aid, Method: c.method, Reply: paxosReply(reply)})
+			return
+		}
+		s.schedule(s.now+s.delay(), &event{kind: EventReply, call: c, result: result{reply, err}})
+	case EventReply, EventTimeout:
+		c := e.call
+		if c.done {
+			return
+		}
+		c.done = true
+		if e.kind == EventTimeout {
+			e.result.err = ErrTimeout
+		}
+		s.record(Event{Kind: e.kind, Task: c.task.name, Call: c.id, Acceptor: c.aid, Method: c.method, Reply: paxosReply(e.result.reply), Err: e.result.err})
+		s.resume(c.task, e.result)
+	}
+}
+
+// handle delivers a request to the Acceptor as a copy, like over a network.
+func (s *Sim) handle(c *call) (proto.Message, error) {
+	kv := s.acceptors[c.aid]
+	req := proto.Clone(c.req)
+	ctx := context.Background()
+
+	var reply proto.Message
+	var err error
+	switch c.method {
+	case "Prepare":
+		reply, err = kv.Prepare(ctx, req.(*core.Proposer))
+	case "Accept":
+		reply, err = kv.Accept(ctx, req.(*core.Proposer))
+	case "Commit":
+		reply, err = kv.Commit(ctx, req.(*core.Proposer))
+	case "LatestVersion":
+		reply, err = kv.LatestVersion(ctx, req.(*core.LatestVersionRequest))
+	case "Fence":
+		reply, err = kv.Fence(ctx, req.(*core.FenceRequest))
+	default:
+		reply, err = kv.Scan(ctx, req.(*core.ScanRequest))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return proto.Clone(reply), nil
+}
+
+// paxosRequest returns the request if it is a paxos one, or nil.
+func paxosRequest(req proto.Message) *core.Proposer {
+	p, _ := req.(*core.Proposer)
+	return p
+}
+
+// paxosReply returns the reply if it is a paxos one, or nil.
+func paxosReply(reply proto.Message) *core.Acceptor {
+	a, _ := reply.(*core.Acceptor)
+	return a
+}
+
+// resume runs the task until it blocks or ends.
+func (s *Sim) resume(t *task, res result) {
+	s.current = t
+	t.wake <- res
+	<-s.yield
+	s.current = nil
+}
+
+// block yields the running task to the simulation, until it is resumed.
+func (s *Sim) block(t *task) result {
+	s.yield <- struct{}{}
+	return <-t.wake
+}
+
+func (s *Sim) delay() time.Duration {
+	d := s.config.MinDelay
+	if span := s.config.MaxDelay - s.config.MinDelay; span > 0 {
+		d += time.Duration(s.rng.Int63n(int64(span)))
+	}
+	return d
+}
+
+func (s *Sim) schedule(at time.Duration, e *event) {
+	s.seq += 1
+	e.at, e.seq = at, s.seq
+	heap.Push(&s.queue, e)
+}
+
+func (s *Sim) record(e Event) {
+	e.At = s.now
+	s.trace = append(s.trace, e)
+	for _, fn := range s.observers {
+		fn(e)
+	}
+}
+
+// event is an event scheduled at a simulated time, the events at the same
+// time run in the order they are scheduled.
+type event struct {
+	at     time.Duration
+	seq    int64
+	kind   string
+	task   *task
+	call   *call
+	result result
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,161 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/khighness/highness-paxos-kv/core"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+// faulty is a network reordering, losing and duplicating messages.
+func faulty(seed int64) Config {
+	return Config{
+		Seed:          seed,
+		AcceptorIds:   []int64{0, 1, 2},
+		MinDelay:      time.Millisecond,
+		MaxDelay:      20 * time.Millisecond,
+		DropRate:      0.1,
+		DuplicateRate: 0.1,
+		Timeout:       50 * time.Millisecond,
+	}
+}
+
+// propose runs competing Proposers on the same instance and returns the
+// values they return.
+func propose(s *Sim, proposers int) []*core.Value {
+	chosen := make([]*core.Value, proposers)
+	for i := 0; i < proposers; i++ {
+		i := i
+		s.Go(fmt.Sprintf("P%d", i+1), func() {
+			s.Sleep(time.Duration(i) * time.Millisecond)
+			p := core.Proposer{
+				Id:  &core.PaxosInstanceId{Key: "k", Ver: 0},
+				Bal: &core.BallotNum{N: 0, ProposerId: int64(i + 1)},
+			}
+			chosen[i], _ = p.ProposeWith(context.Background(), s, s.config.AcceptorIds, &core.Value{Vi64: int64(i + 1)})
+		})
+	}
+	return chosen
+}
+
+func TestSim_Safety(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		s := New(faulty(seed))
+		chosen := propose(s, 3)
+		if err := s.Run(); err != nil {
+			t.Fatalf("seed %d: %v\n%s", seed, err, FormatTrace(s.Trace()))
+		}
+
+		for i, val := range chosen {
+			if val == nil || val.Vi64 != chosen[0].Vi64 {
+				t.Fatalf("seed %d: proposer %d returns %v, proposer 1 returns %v\n%s",
+					seed, i+1, val, chosen[0], FormatTrace(s.Trace()))
+			}
+		}
+	}
+}
+
+func TestSim_Replay(t *testing.T) {
+	r := require.New(t)
+
+	run := func(seed int64) string {
+		s := New(faulty(seed))
+		propose(s, 3)
+		r.Nil(s.Run())
+		return FormatTrace(s.Trace())
+	}
+
+	trace := run(7)
+	r.Contains(trace, EventDrop)
+	r.Equal(trace, run(7))
+	r.NotEqual(trace, run(8))
+}
+
+func TestSim_StepLimit(t *testing.T) {
+	r := require.New(t)
+
+	// no quorum is reachable, the Proposer retries forever
+	c := faulty(1)
+	c.DropRate = 1
+	c.MaxSteps = 1000
+	s := New(c)
+	propose(s, 1)
+	r.ErrorIs(s.Run(), ErrStepLimit)
+}
+
+func TestSim_Client(t *testing.T) {
+	r := require.New(t)
+
+	var read int
+	for seed := int64(0); seed < 50; seed++ {
+		s := New(faulty(seed))
+		vers := make([]int64, 2)
+		for i := range vers {
+			i := i
+			s.Go(fmt.Sprintf("C%d", i+1), func() {
+				c := s.Client(int64(i + 1))
+				// a write fails if no quorum answers the versions in time
+				for {
+					ver, err := c.Set("k", &core.Value{Vi64: int64(i + 1)})
+					if err == nil {
+						vers[i] = ver
+						return
+					}
+					s.Sleep(time.Millisecond)
+				}
+			})
+		}
+		r.Nil(s.Run(), "seed %d", seed)
+		r.NotEqual(vers[0], vers[1], "seed %d", seed)
+
+		var val *core.Value
+		var ver int64
+		var err error
+		s.Go("reader", func() {
+			val, ver, err = s.Client(3).Get("k")
+		})
+		r.Nil(s.Run(), "seed %d", seed)
+		if err != nil {
+			// no quorum answered in time
+			r.ErrorIs(err, core.ErrNoEnoughQuorum, "seed %d", seed)
+			continue
+		}
+		// the value of the later write is read
+		latest, writer := vers[0], int64(1)
+		if vers[1] > latest {
+			latest, writer = vers[1], 2
+		}
+		r.Equal(latest, ver, "seed %d", seed)
+		r.Equal(writer, val.Vi64, "seed %d", seed)
+		read += 1
+	}
+	r.Positive(read)
+}
+
+func TestSim_ClientClock(t *testing.T) {
+	r := require.New(t)
+
+	s := New(Config{Seed: 1, AcceptorIds: []int64{0, 1, 2}, MinDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	var set, before, after error
+	s.Go("client", func() {
+		c := s.Client(1)
+		if _, set = c.SetWithTTL("k", &core.Value{Vi64: 1}, time.Hour); set != nil {
+			return
+		}
+		_, _, before = c.Get("k")
+		// the value expires in simulated time, without waiting for an hour
+		s.Sleep(time.Hour)
+		_, _, after = c.Get("k")
+	})
+	r.Nil(s.Run())
+	r.Nil(set)
+	r.Nil(before)
+	r.Equal(core.ErrNotFound, after)
+}
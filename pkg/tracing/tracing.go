@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// @Author KHighness
+// @Update 2022-10-15
+
+// Config configures the tracing of the process.
+type Config struct {
+	// ServiceName is the name of the process recorded in every span.
+	ServiceName string
+	// Output is where spans are written as JSON lines, "stdout" or a file
+	// path. Spans are dropped if it is empty.
+	Output string
+	// SampleRatio is the ratio of traces sampled, sampling all if it is not
+	// in (0, 1). Traces started remotely follow the sampling of the caller.
+	SampleRatio float64
+}
+
+// Setup installs the global TracerProvider exporting spans as configured.
+// The returned function flushes pending spans and closes the output, it must
+// be called before the process exits.
+func Setup(c Config) (func(context.Context) error, error) {
+	if c.Output == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var w io.WriteCloser = nopCloser{os.Stdout}
+	if c.Output != "stdout" {
+		f, err := os.OpenFile(c.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		w = f
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if c.SampleRatio > 0 && c.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(c.SampleRatio)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(c.ServiceName),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if e := w.Close(); err == nil {
+			err = e
+		}
+		return err
+	}, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+// @Author KHighness
+// @Update 2022-10-16
+
+func TestSetup_File(t *testing.T) {
+	r := require.New(t)
+
+	output := filepath.Join(t.TempDir(), "trace.json")
+	shutdown, err := Setup(Config{ServiceName: "test", Output: output})
+	r.Nil(err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "work")
+	span.End()
+	r.Nil(shutdown(context.Background()))
+
+	data, err := os.ReadFile(output)
+	r.Nil(err)
+	r.Contains(string(data), `"Name":"work"`)
+	r.Contains(string(data), `"Value":"test"`)
+}